@@ -45,6 +45,11 @@ type e struct {
 	Names []string `scraper:"ul li"`
 }
 
+type f struct {
+	HasList    bool `scraper:"ul" scrapeType:"exists"`
+	HasOrdered bool `scraper:"ol" scrapeType:"exists"`
+}
+
 func TestDecoder(t *testing.T) {
 	errFoo := errors.New("Foo")
 
@@ -82,6 +87,7 @@ func TestDecoder(t *testing.T) {
 		{"InvalidUnmarshalError 1", "testdata/a.html", nil, &a{}, nil, &InvalidUnmarshalError{reflect.TypeOf(nil), reflect.Ptr}},
 		{"InvalidUnmarshalError 2", "testdata/a.html", nil, new(d), new(d), &InvalidUnmarshalError{reflect.TypeOf(d(0)), reflect.Struct}},
 		{"E", "testdata/e.html", nil, &e{[]string{"one", "two", "three"}}, &e{}, nil},
+		{"F", "testdata/e.html", nil, &f{HasList: true}, &f{}, nil},
 	}
 
 	for _, test := range tests {
@@ -16,17 +16,208 @@ package scraper
 
 import (
 	"reflect"
+	"strconv"
+	"strings"
 )
 
+// locatedError is implemented by the errors that record the root struct,
+// field path and selector of the field they occurred in
+type locatedError interface {
+	error
+	location() (structName, field, selector *string)
+}
+
 // An UnmarshalTypeError describes a value that was
 // not appropriate for a value of a specific Go type.
 type UnmarshalTypeError struct {
-	Value string       // description of value - "bool", "array", "number -5"
-	Type  reflect.Type // type of Go value it could not be assigned to
+	Value    string       // description of value - "bool", "array", "number -5"
+	Type     reflect.Type // type of Go value it could not be assigned to
+	Struct   string       // name of the root struct type containing the field
+	Field    string       // the full path from the root struct to the field - "Listing.Prices[3].Amount"
+	Selector string       // the CSS selector that matched the value
 }
 
 func (e *UnmarshalTypeError) Error() string {
-	return "scraper: cannot unmarshal " + e.Value + " into Go value of type " + e.Type.String()
+	if e.Field == "" {
+		return "scraper: cannot unmarshal " + e.Value + " into Go value of type " + e.Type.String()
+	}
+
+	msg := "scraper: cannot unmarshal " + e.Value + " into Go struct field " + joinPath(e.Struct, e.Field) + " of type " + e.Type.String()
+	if e.Selector != "" {
+		msg += " (selector " + strconv.Quote(e.Selector) + ")"
+	}
+	return msg
+}
+
+func (e *UnmarshalTypeError) location() (*string, *string, *string) {
+	return &e.Struct, &e.Field, &e.Selector
+}
+
+// A NoMatchError is returned when the selector of a required
+// field does not match any elements
+type NoMatchError struct {
+	Struct   string // name of the root struct type containing the field
+	Field    string // the full path from the root struct to the field
+	Selector string // the CSS selector that did not match
+}
+
+func (e *NoMatchError) Error() string {
+	return "scraper: no elements matched selector " + strconv.Quote(e.Selector) + " for required field " + joinPath(e.Struct, e.Field)
+}
+
+func (e *NoMatchError) location() (*string, *string, *string) {
+	return &e.Struct, &e.Field, &e.Selector
+}
+
+// A MultipleMatchError is returned when the selector of a field with
+// the unique option matches more than one element
+type MultipleMatchError struct {
+	Struct   string // name of the root struct type containing the field
+	Field    string // the full path from the root struct to the field
+	Selector string // the CSS selector that matched
+	Count    int    // the number of matching elements
+}
+
+func (e *MultipleMatchError) Error() string {
+	return "scraper: " + strconv.Itoa(e.Count) + " elements matched selector " + strconv.Quote(e.Selector) + " for unique field " + joinPath(e.Struct, e.Field)
+}
+
+func (e *MultipleMatchError) location() (*string, *string, *string) {
+	return &e.Struct, &e.Field, &e.Selector
+}
+
+// A RegexMismatchError is returned when the scrapeRegex
+// of a field does not match the field's value
+type RegexMismatchError struct {
+	Value    string // the value that the regular expression was applied to
+	Regex    string // the regular expression
+	Struct   string // name of the root struct type containing the field
+	Field    string // the full path from the root struct to the field
+	Selector string // the CSS selector that matched the value
+}
+
+func (e *RegexMismatchError) Error() string {
+	msg := "scraper: regex " + strconv.Quote(e.Regex) + " does not match " + strconv.Quote(e.Value)
+	if e.Field != "" {
+		msg += " for field " + joinPath(e.Struct, e.Field)
+	}
+
+	if e.Selector != "" {
+		msg += " (selector " + strconv.Quote(e.Selector) + ")"
+	}
+	return msg
+}
+
+func (e *RegexMismatchError) location() (*string, *string, *string) {
+	return &e.Struct, &e.Field, &e.Selector
+}
+
+// An UnknownFilterError is returned when the scrapeFilter tag of a field names
+// a filter that is neither built in nor registered with the Filter Option
+type UnknownFilterError struct {
+	Filter   string // the name of the filter
+	Struct   string // name of the root struct type containing the field
+	Field    string // the full path from the root struct to the field
+	Selector string // the CSS selector that matched the value
+}
+
+func (e *UnknownFilterError) Error() string {
+	msg := "scraper: unknown filter " + strconv.Quote(e.Filter)
+	if e.Field != "" {
+		msg += " for field " + joinPath(e.Struct, e.Field)
+	}
+
+	if e.Selector != "" {
+		msg += " (selector " + strconv.Quote(e.Selector) + ")"
+	}
+	return msg
+}
+
+func (e *UnknownFilterError) location() (*string, *string, *string) {
+	return &e.Struct, &e.Field, &e.Selector
+}
+
+// A FieldError records the field in which an error that does not locate its
+// own field occurred, such as an error returned by a TextUnmarshaler, a filter
+// or encoding/json.  The original error is available using errors.Is and
+// errors.As
+type FieldError struct {
+	Err      error  // the original error
+	Struct   string // name of the root struct type containing the field
+	Field    string // the full path from the root struct to the field
+	Selector string // the CSS selector that matched the value
+}
+
+func (e *FieldError) Error() string {
+	msg := "scraper: field " + joinPath(e.Struct, e.Field)
+	if e.Selector != "" {
+		msg += " (selector " + strconv.Quote(e.Selector) + ")"
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+// Unwrap returns the original error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+func (e *FieldError) location() (*string, *string, *string) {
+	return &e.Struct, &e.Field, &e.Selector
+}
+
+// UnmarshalErrors is returned by an Unmarshaler configured with ContinueOnError
+// and holds every error that occurred while unmarshaling
+type UnmarshalErrors []error
+
+func (e UnmarshalErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "scraper: " + strconv.Itoa(len(e)) + " errors occurred:\n\t" + strings.Join(msgs, "\n\t")
+}
+
+// Unwrap returns the individual errors so that the list can be searched using
+// errors.Is and errors.As
+func (e UnmarshalErrors) Unwrap() []error {
+	return e
+}
+
+// append adds err to the list, flattening nested
+// lists and ignoring nil errors
+func (e UnmarshalErrors) append(err error) UnmarshalErrors {
+	if errs, ok := err.(UnmarshalErrors); ok {
+		return append(e, errs...)
+	} else if err != nil {
+		return append(e, err)
+	}
+	return e
+}
+
+// err returns the list as an error, or nil if the list is empty
+func (e UnmarshalErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// An UnsupportedTypeError is returned when a struct field has a
+// type that cannot be unmarshaled from an HTML document
+type UnsupportedTypeError struct {
+	Field string       // name of the struct field, if known - "Product.Tags"
+	Type  reflect.Type // the unsupported type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	if e.Field == "" {
+		return "scraper: unsupported type " + e.Type.String()
+	}
+	return "scraper: unsupported type " + e.Type.String() + " for field " + e.Field
 }
 
 // An InvalidUnmarshalError describes an invalid argument passed to Unmarshal.
@@ -15,6 +15,7 @@
 package scraper
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -29,7 +30,19 @@ func TestError(t *testing.T) {
 		input error
 		want  string
 	}{
-		{"UnmarshalTypeError", &UnmarshalTypeError{"foo", reflect.TypeOf(0)}, "scraper: cannot unmarshal foo into Go value of type int"},
+		{"UnmarshalTypeError", &UnmarshalTypeError{Value: "foo", Type: reflect.TypeOf(0)}, "scraper: cannot unmarshal foo into Go value of type int"},
+		{"UnmarshalTypeError with field", &UnmarshalTypeError{Value: "number abc", Type: reflect.TypeOf(0), Struct: "Page", Field: "Listing.Prices[3].Amount", Selector: ".amount"}, `scraper: cannot unmarshal number abc into Go struct field Page.Listing.Prices[3].Amount of type int (selector ".amount")`},
+		{"NoMatchError", &NoMatchError{Struct: "Page", Field: "Listing.Price", Selector: ".price"}, `scraper: no elements matched selector ".price" for required field Page.Listing.Price`},
+		{"MultipleMatchError", &MultipleMatchError{Struct: "Page", Field: "Price", Selector: ".price", Count: 2}, `scraper: 2 elements matched selector ".price" for unique field Page.Price`},
+		{"RegexMismatchError", &RegexMismatchError{Value: "n/a", Regex: `\d+`}, `scraper: regex "\\d+" does not match "n/a"`},
+		{"RegexMismatchError with field", &RegexMismatchError{Value: "n/a", Regex: `\d+`, Struct: "Page", Field: "Price", Selector: ".price"}, `scraper: regex "\\d+" does not match "n/a" for field Page.Price (selector ".price")`},
+		{"UnknownFilterError", &UnknownFilterError{Filter: "slug"}, `scraper: unknown filter "slug"`},
+		{"UnknownFilterError with field", &UnknownFilterError{Filter: "slug", Struct: "Page", Field: "Tags[0]", Selector: ".tag"}, `scraper: unknown filter "slug" for field Page.Tags[0] (selector ".tag")`},
+		{"FieldError", &FieldError{Err: errors.New("bad value"), Struct: "Page", Field: "Name", Selector: ".name"}, `scraper: field Page.Name (selector ".name"): bad value`},
+		{"UnmarshalErrors single", UnmarshalErrors{&UnmarshalTypeError{Value: "foo", Type: reflect.TypeOf(0)}}, "scraper: cannot unmarshal foo into Go value of type int"},
+		{"UnmarshalErrors", UnmarshalErrors{&UnmarshalTypeError{Value: "foo", Type: reflect.TypeOf(0)}, &UnmarshalTypeError{Value: "bar", Type: reflect.TypeOf(0)}}, "scraper: 2 errors occurred:\n\tscraper: cannot unmarshal foo into Go value of type int\n\tscraper: cannot unmarshal bar into Go value of type int"},
+		{"UnsupportedTypeError", &UnsupportedTypeError{Type: reflect.TypeOf(0i)}, "scraper: unsupported type complex128"},
+		{"UnsupportedTypeError with field", &UnsupportedTypeError{"T.Tags", reflect.TypeOf(map[string]int{})}, "scraper: unsupported type map[string]int for field T.Tags"},
 		{"nil InvalidUnmarshalError", &InvalidUnmarshalError{reflect.TypeOf(nil), reflect.Ptr}, "scraper: Unmarshal(nil)"},
 		{"int InvalidUnmarshalError", &InvalidUnmarshalError{reflect.TypeOf(0), reflect.Struct}, "scraper: Unmarshal(non-struct int)"},
 		{"nil ptr InvalidUnmarshalError", &InvalidUnmarshalError{reflect.TypeOf(tes), reflect.Ptr}, "scraper: Unmarshal(nil *scraper.testErrStruct)"},
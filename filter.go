@@ -0,0 +1,186 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"reflect"
+	"strings"
+)
+
+// builtinFilters are the filters available to every Unmarshaler
+var builtinFilters = map[string]FilterFunc{
+	"trim":     trimFilter,
+	"lower":    lowerFilter,
+	"upper":    upperFilter,
+	"collapse": collapseFilter,
+	"replace":  replaceFilter,
+	"prefix":   prefixFilter,
+	"suffix":   suffixFilter,
+}
+
+// FilterFunc transforms a value before it is assigned to a field.  The args are
+// the colon separated arguments following the filter's name in the scrapeFilter
+// tag, so `replace:,:` calls the replace filter with the args "," and ""
+type FilterFunc func(value string, args ...string) (string, error)
+
+// Filter registers a named filter for use in scrapeFilter tags.  A registered
+// filter replaces any built in filter with the same name
+func Filter(name string, fn FilterFunc) Option {
+	return func(u *Unmarshaler) error {
+		if u.filters == nil {
+			u.filters = make(map[string]FilterFunc)
+		}
+		u.filters[name] = fn
+		return nil
+	}
+}
+
+// filterCall is a single step of a scrapeFilter pipeline
+type filterCall struct {
+	name string
+	args []string
+}
+
+// parseFilters parses a pipeline of filters separated by pipes (|)
+func parseFilters(str string) (calls []filterCall) {
+	for _, step := range strings.Split(str, "|") {
+		fields := strings.Split(step, ":")
+		calls = append(calls, filterCall{name: strings.TrimSpace(fields[0]), args: fields[1:]})
+	}
+	return calls
+}
+
+// checkFilters makes sure that every filter named by the fields of the struct
+// type t, and of the structs nested within it, is either built in or registered
+// with the Filter Option.  This way a misspelled filter is reported even when
+// its field does not match anything
+func (u *Unmarshaler) checkFilters(t reflect.Type, path string, checked map[reflect.Type]bool) error {
+	if checked[t] {
+		return nil
+	}
+	checked[t] = true
+
+	plan, err := cachedPlan(t)
+	if err != nil {
+		return err
+	}
+
+	for _, fp := range plan.fields {
+		fieldPath := joinPath(path, fp.name)
+		for tg := fp.tag; tg != nil; tg = tg.value {
+			if err = u.checkTagFilters(tg, fieldPath); err == nil && tg.key != nil {
+				err = u.checkTagFilters(tg.key, fieldPath)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		ft := leafType(t.Field(fp.index).Type)
+		if ft.Kind() == reflect.Map && fp.tag.key != nil {
+			ft = leafType(ft.Elem())
+		}
+
+		if ft.Kind() == reflect.Struct && !implementsUnmarshaler(ft) && fp.tag.typ != embeddedJSON {
+			if err = u.checkFilters(ft, fieldPath, checked); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (u *Unmarshaler) checkTagFilters(t *tag, path string) error {
+	for _, call := range t.filters {
+		if _, found := u.filters[call.name]; !found {
+			if _, found = builtinFilters[call.name]; !found {
+				return &UnknownFilterError{Filter: call.name, Field: path, Selector: t.query}
+			}
+		}
+	}
+	return nil
+}
+
+// filter passes value through each of the filters in order
+func (u *Unmarshaler) filter(calls []filterCall, value string) (string, error) {
+	var err error
+	for _, call := range calls {
+		fn, found := u.filters[call.name]
+		if !found {
+			if fn, found = builtinFilters[call.name]; !found {
+				return "", &UnknownFilterError{Filter: call.name}
+			}
+		}
+
+		if value, err = fn(value, call.args...); err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
+
+// trimFilter removes surrounding whitespace or, given an
+// argument, the surrounding characters in the argument
+func trimFilter(value string, args ...string) (string, error) {
+	if len(args) > 0 {
+		return strings.Trim(value, args[0]), nil
+	}
+	return strings.TrimSpace(value), nil
+}
+
+func lowerFilter(value string, args ...string) (string, error) {
+	return strings.ToLower(value), nil
+}
+
+func upperFilter(value string, args ...string) (string, error) {
+	return strings.ToUpper(value), nil
+}
+
+// collapseFilter replaces each run of whitespace with a
+// single space and removes surrounding whitespace
+func collapseFilter(value string, args ...string) (string, error) {
+	return normalizeSpace(value), nil
+}
+
+// replaceFilter replaces every occurrence of the first
+// argument with the second argument
+func replaceFilter(value string, args ...string) (string, error) {
+	if len(args) == 0 {
+		return value, nil
+	}
+
+	var replacement string
+	if len(args) > 1 {
+		replacement = args[1]
+	}
+	return strings.Replace(value, args[0], replacement, -1), nil
+}
+
+// prefixFilter removes the argument from the start of the value
+func prefixFilter(value string, args ...string) (string, error) {
+	for _, prefix := range args {
+		value = strings.TrimPrefix(value, prefix)
+	}
+	return value, nil
+}
+
+// suffixFilter removes the argument from the end of the value
+func suffixFilter(value string, args ...string) (string, error) {
+	for _, suffix := range args {
+		value = strings.TrimSuffix(value, suffix)
+	}
+	return value, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{"trim", "trim", "  foo \n", "foo", nil},
+		{"trim characters", "trim:*", "**foo*", "foo", nil},
+		{"lower", "lower", "FoO", "foo", nil},
+		{"upper", "upper", "FoO", "FOO", nil},
+		{"collapse", "collapse", " foo \n\t bar  ", "foo bar", nil},
+		{"replace", "replace:,:", "1,299,000", "1299000", nil},
+		{"replace with", "replace:-:/", "2019-05-01", "2019/05/01", nil},
+		{"prefix", "prefix:$", "$12", "12", nil},
+		{"suffix", "suffix: USD", "12 USD", "12", nil},
+		{"pipeline", "trim|lower|collapse|replace:,:|prefix:$", "  $1,299  ", "1299", nil},
+		{"unknown", "trim|foo", "bar", "", &UnknownFilterError{Filter: "foo"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := NewUnmarshaler(nil)
+			got, gotErr := u.filter(parseFilters(test.filters), test.input)
+			if !reflect.DeepEqual(test.wantErr, gotErr) {
+				t.Errorf("Wanted error %v got %v", test.wantErr, gotErr)
+			} else if test.want != got {
+				t.Errorf("Wanted %q got %q", test.want, got)
+			}
+		})
+	}
+}
+
+type filterTest struct {
+	Price float64  `scraper:".price" scrapeFilter:"replace:,:|prefix:$"`
+	SKU   string   `scraper:".sku" scrapeFilter:"collapse|upper"`
+	Tags  []string `scraper:".tag" scrapeFilter:"slug"`
+}
+
+type filterNestedTest struct {
+	Card struct {
+		Specs map[string]string `scraper:".spec" scrapeKey:"dt" scrapeValue:"dd" scrapeFilter:"sulg"`
+	} `scraper:".card"`
+}
+
+func TestFilterOption(t *testing.T) {
+	input := `<span class="price">$1,299.00</span>
+		<span class="sku"> wd
+			42 </span>
+		<span class="tag">Power Tools</span><span class="tag">bad</span>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	errBad := errors.New("bad tag")
+	slug := func(value string, args ...string) (string, error) {
+		if value == "bad" {
+			return "", errBad
+		}
+		return strings.ToLower(strings.Replace(value, " ", "-", -1)), nil
+	}
+
+	got := &filterTest{}
+	err = NewUnmarshaler(root, Filter("slug", slug), ContinueOnError()).Unmarshal(got)
+	if !errors.Is(err, errBad) {
+		t.Errorf("Wanted error %v got %v", errBad, err)
+	}
+
+	want := &filterTest{Price: 1299, SKU: "WD 42", Tags: []string{"power-tools"}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted %+v got %+v", want, got)
+	}
+
+	err = NewUnmarshaler(root).Unmarshal(&filterTest{})
+	wantErr := &UnknownFilterError{Filter: "slug", Struct: "filterTest", Field: "Tags", Selector: ".tag"}
+	if !reflect.DeepEqual(wantErr, err) {
+		t.Errorf("Wanted error %v got %v", wantErr, err)
+	}
+
+	// filters are checked even when nothing matches
+	err = NewUnmarshaler(root, ContinueOnError()).Unmarshal(&filterNestedTest{})
+	wantErr = &UnknownFilterError{Filter: "sulg", Struct: "filterNestedTest", Field: "Card.Specs", Selector: ".spec"}
+	if !reflect.DeepEqual(wantErr, err) {
+		t.Errorf("Wanted error %v got %v", wantErr, err)
+	}
+}
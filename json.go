@@ -0,0 +1,66 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrNoAssignment indicates that the text of an element did not contain
+	// the JavaScript assignment given in a json scrapeType
+	ErrNoAssignment = errors.New("JavaScript assignment not found")
+)
+
+// unmarshalJSON decodes the JSON in the text content of n into f.  If the tag
+// names an assignment target (window.__INITIAL_STATE__ for instance) then the
+// JSON value following the target's assignment operator is decoded
+func (u *Unmarshaler) unmarshalJSON(f *field, n *selection) error {
+	text, err := u.value(f, n)
+	if err != nil {
+		return err
+	}
+
+	if target := f.tag.detail; target != "" {
+		var found bool
+		if text, found = assignedValue(text, target); !found {
+			return ErrNoAssignment
+		}
+	}
+
+	// Decoder stops after the first value, so any
+	// trailing semicolons or statements are ignored
+	return json.NewDecoder(strings.NewReader(text)).Decode(f.Addr().Interface())
+}
+
+// assignedValue returns the script text following the assignment of
+// the given target, such as `window.__INITIAL_STATE__ = `
+func assignedValue(script, target string) (string, bool) {
+	for i := strings.Index(script, target); i >= 0; {
+		rest := strings.TrimSpace(script[i+len(target):])
+		if strings.HasPrefix(rest, "=") && !strings.HasPrefix(rest, "==") {
+			return rest[1:], true
+		}
+
+		next := strings.Index(script[i+len(target):], target)
+		if next < 0 {
+			break
+		}
+		i += len(target) + next
+	}
+	return "", false
+}
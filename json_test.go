@@ -0,0 +1,90 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"golang.org/x/net/html"
+)
+
+type jsonTestProduct struct {
+	Name  string   `json:"name"`
+	Price float64  `json:"price"`
+	Tags  []string `json:"tags"`
+}
+
+type jsonTest struct {
+	Title   string                 `scraper:"h1"`
+	Product *jsonTestProduct       `scraper:"script[type='application/ld+json']" scrapeType:"json"`
+	State   map[string]interface{} `scraper:"script#state" scrapeType:"json:window.__INITIAL_STATE__"`
+	Tags    []string               `scraper:"script#tags" scrapeType:"json:tags"`
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	input := `<h1>Widget</h1>
+	<script type="application/ld+json">{"name": "Widget", "price": 9.99, "tags": ["a", "b"]}</script>
+	<script id="state">
+		if (window.__INITIAL_STATE__ == null) {
+			window.__INITIAL_STATE__ = {"page": 2};
+		}
+		render();
+	</script>
+	<script id="tags">var tags=["x","y"]</script>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	got := &jsonTest{}
+	if err := NewUnmarshaler(root).Unmarshal(got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := &jsonTest{
+		Title:   "Widget",
+		Product: &jsonTestProduct{Name: "Widget", Price: 9.99, Tags: []string{"a", "b"}},
+		State:   map[string]interface{}{"page": float64(2)},
+		Tags:    []string{"x", "y"},
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"no assignment", `<script id="state">render();</script>`},
+		{"syntax", `<script id="state">window.__INITIAL_STATE__ = {"page": </script>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatalf("Failed to parse html: %v", err)
+			}
+
+			if err := NewUnmarshaler(root).Unmarshal(&jsonTest{}); err == nil {
+				t.Errorf("Wanted an error")
+			}
+		})
+	}
+}
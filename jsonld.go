@@ -0,0 +1,194 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// ErrNoJSONLD indicates that the document does not contain
+	// any JSON-LD objects of the requested type
+	ErrNoJSONLD = errors.New("No matching JSON-LD found")
+
+	errTrailingData = errors.New("Invalid data after top-level JSON value")
+)
+
+// jsonLDType is the script type for embedded JSON-LD
+const jsonLDType = "application/ld+json"
+
+// ExtractJSONLD decodes every application/ld+json script found within root.
+// Scripts may contain a single object, an array of objects or an object with
+// an @graph array, and each of the objects is returned separately in document
+// order.  Scripts that cannot be decoded are skipped and their errors are
+// returned as UnmarshalErrors along with the objects that could be decoded
+func ExtractJSONLD(root *html.Node) (objects []map[string]interface{}, err error) {
+	return extractJSONLD(root, false)
+}
+
+// extractJSONLD decodes the JSON-LD objects within root.  When useNumber is
+// set, numbers are decoded as json.Number so that the objects can be decoded
+// again without losing precision
+func extractJSONLD(root *html.Node, useNumber bool) (objects []map[string]interface{}, err error) {
+	var errs UnmarshalErrors
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.Script {
+			s := &selection{n}
+			if strings.EqualFold(strings.TrimSpace(s.attr("type")), jsonLDType) {
+				var v interface{}
+				if err := decodeScript(s.text(), &v, useNumber); err == nil {
+					objects = appendJSONLD(objects, v)
+				} else {
+					errs = errs.append(err)
+				}
+			}
+			return
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+
+	f(root)
+	return objects, errs.err()
+}
+
+// decodeScript decodes the JSON value in text into v.  Like json.Unmarshal,
+// anything other than whitespace following the value is an error
+func decodeScript(text string, v interface{}, useNumber bool) error {
+	dec := json.NewDecoder(strings.NewReader(text))
+	if useNumber {
+		dec.UseNumber()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = errTrailingData
+		}
+		return err
+	}
+	return nil
+}
+
+// appendJSONLD appends the objects contained in v to objects, flattening
+// arrays and @graph arrays
+func appendJSONLD(objects []map[string]interface{}, v interface{}) []map[string]interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			objects = appendJSONLD(objects, item)
+		}
+	case map[string]interface{}:
+		if graph, found := v["@graph"]; found {
+			objects = appendJSONLD(objects, graph)
+		} else {
+			objects = append(objects, v)
+		}
+	}
+	return objects
+}
+
+// UnmarshalJSONLD decodes the JSON-LD objects within root whose @type matches
+// schemaType into v.  Types match either exactly or by their final component,
+// so "Product" matches "Product", "schema:Product" and "https://schema.org/Product".
+// An empty schemaType matches every object.  When v is a pointer to a slice, each
+// matching object is appended to the slice.  Otherwise, the first matching object
+// is decoded into v.  ErrNoJSONLD is returned when nothing matches.  Scripts that
+// cannot be decoded are ignored, ExtractJSONLD can be used to find their errors
+func UnmarshalJSONLD(root *html.Node, schemaType string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidUnmarshalError{reflect.TypeOf(v), reflect.Ptr}
+	}
+
+	// undecodable scripts are not fatal
+	objects, _ := extractJSONLD(root, true)
+
+	var matches []map[string]interface{}
+	for _, object := range objects {
+		if schemaType == "" || hasJSONLDType(object["@type"], schemaType) {
+			matches = append(matches, object)
+		}
+	}
+
+	if len(matches) == 0 {
+		return ErrNoJSONLD
+	}
+
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Slice {
+		return decodeJSONLD(matches[0], v)
+	}
+
+	for _, match := range matches {
+		elem := reflect.New(rv.Type().Elem())
+		if err := decodeJSONLD(match, elem.Interface()); err != nil {
+			return err
+		}
+		rv.Set(reflect.Append(rv, elem.Elem()))
+	}
+	return nil
+}
+
+// decodeJSONLD decodes a single JSON-LD object into v
+func decodeJSONLD(object map[string]interface{}, v interface{}) error {
+	buf, err := json.Marshal(object)
+	if err == nil {
+		err = json.Unmarshal(buf, v)
+	}
+	return err
+}
+
+// hasJSONLDType determines if the @type value, which may be a string or an
+// array of strings, contains schemaType
+func hasJSONLDType(value interface{}, schemaType string) bool {
+	switch value := value.(type) {
+	case string:
+		return schemaTypeMatches(value, schemaType)
+	case []interface{}:
+		for _, v := range value {
+			if hasJSONLDType(v, schemaType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaTypeMatches determines if value names schemaType, either exactly or
+// by its final component, such as "https://schema.org/Product" for "Product"
+func schemaTypeMatches(value, schemaType string) bool {
+	if value == schemaType {
+		return true
+	}
+
+	if i := strings.LastIndexAny(value, "/:#"); i >= 0 {
+		return value[i+1:] == schemaType
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"golang.org/x/net/html"
+)
+
+const jsonLDTestDocument = `<html><head>
+<script type="application/ld+json">{"@type": "Product", "name": "Widget", "offers": {"@type": "Offer", "price": "9.99"}}</script>
+<script type="application/ld+json">[{"@type": "BreadcrumbList"}, {"@type": ["Article", "NewsArticle"], "headline": "News"}]</script>
+<script type="application/ld+json">{"@context": "https://schema.org", "@graph": [{"@type": "https://schema.org/Offer", "price": "1.00"}]}</script>
+<script type="application/ld+json">{"@type": "Inventory", "count": 12345678901234567}</script>
+<script type="application/ld+json">{"broken": </script>
+<script type="application/ld+json">{"@type": "Product"} {"@type": "Product"}</script>
+<script type="text/javascript">{"@type": "Product"}</script>
+</head></html>`
+
+type jsonLDTestOffer struct {
+	Price string `json:"price"`
+}
+
+type jsonLDTestProduct struct {
+	Name   string          `json:"name"`
+	Offers jsonLDTestOffer `json:"offers"`
+}
+
+func parseJSONLDTest(t *testing.T) *html.Node {
+	root, err := html.Parse(strings.NewReader(jsonLDTestDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+	return root
+}
+
+func TestExtractJSONLD(t *testing.T) {
+	objects, err := ExtractJSONLD(parseJSONLDTest(t))
+	if errs, ok := err.(UnmarshalErrors); !ok || len(errs) != 2 {
+		t.Errorf("Wanted errors for the two broken scripts got %v", err)
+	}
+
+	var got []interface{}
+	for _, object := range objects {
+		got = append(got, object["@type"])
+	}
+
+	want := []interface{}{"Product", "BreadcrumbList", []interface{}{"Article", "NewsArticle"}, "https://schema.org/Offer", "Inventory"}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestUnmarshalJSONLD(t *testing.T) {
+	root := parseJSONLDTest(t)
+
+	product := &jsonLDTestProduct{}
+	if err := UnmarshalJSONLD(root, "Product", product); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	} else if diff := deep.Equal(&jsonLDTestProduct{"Widget", jsonLDTestOffer{"9.99"}}, product); diff != nil {
+		t.Error(diff)
+	}
+
+	offers := []jsonLDTestOffer{}
+	if err := UnmarshalJSONLD(root, "Offer", &offers); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	} else if diff := deep.Equal([]jsonLDTestOffer{{"1.00"}}, offers); diff != nil {
+		t.Error(diff)
+	}
+
+	article := map[string]interface{}{}
+	if err := UnmarshalJSONLD(root, "NewsArticle", &article); err != nil || article["headline"] != "News" {
+		t.Errorf("Wanted the news article got %v (%v)", article, err)
+	}
+
+	inventory := &struct {
+		Count uint64 `json:"count"`
+	}{}
+	if err := UnmarshalJSONLD(root, "Inventory", inventory); err != nil || inventory.Count != 12345678901234567 {
+		t.Errorf("Wanted count 12345678901234567 got %d (%v)", inventory.Count, err)
+	}
+
+	if err := UnmarshalJSONLD(root, "Recipe", product); err != ErrNoJSONLD {
+		t.Errorf("Wanted error %v got %v", ErrNoJSONLD, err)
+	}
+
+	if _, ok := UnmarshalJSONLD(root, "Product", nil).(*InvalidUnmarshalError); !ok {
+		t.Errorf("Wanted *InvalidUnmarshalError")
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"golang.org/x/net/html"
+)
+
+// OpenGraph holds the Open Graph properties (https://ogp.me) of a page.  It
+// implements HTMLUnmarshaler and is usually assigned from the document head:
+//
+//	type Page struct {
+//	    OpenGraph scraper.OpenGraph `scraper:"head"`
+//	}
+type OpenGraph struct {
+	Title       string   `scraper:"meta[property='og:title']" scrapeType:"attr:content"`
+	Type        string   `scraper:"meta[property='og:type']" scrapeType:"attr:content"`
+	URL         string   `scraper:"meta[property='og:url']" scrapeType:"attr:content"`
+	Description string   `scraper:"meta[property='og:description']" scrapeType:"attr:content"`
+	SiteName    string   `scraper:"meta[property='og:site_name']" scrapeType:"attr:content"`
+	Locale      string   `scraper:"meta[property='og:locale']" scrapeType:"attr:content"`
+	Images      []string `scraper:"meta[property='og:image'], meta[property='og:image:url']" scrapeType:"attr:content"`
+	Videos      []string `scraper:"meta[property='og:video'], meta[property='og:video:url']" scrapeType:"attr:content"`
+}
+
+// openGraph has the fields of OpenGraph without its methods, so
+// that UnmarshalHTML can unmarshal into it without recursing
+type openGraph OpenGraph
+
+// UnmarshalHTML assigns the Open Graph meta tags found within n
+func (og *OpenGraph) UnmarshalHTML(n *html.Node) error {
+	return unmarshalMeta(n, (*openGraph)(og))
+}
+
+// TwitterCard holds the Twitter card properties of a page.  Twitter card
+// meta tags may use either the name or the property attribute, and both
+// are recognized
+type TwitterCard struct {
+	Card        string `scraper:"meta[name='twitter:card'], meta[property='twitter:card']" scrapeType:"attr:content"`
+	Site        string `scraper:"meta[name='twitter:site'], meta[property='twitter:site']" scrapeType:"attr:content"`
+	Creator     string `scraper:"meta[name='twitter:creator'], meta[property='twitter:creator']" scrapeType:"attr:content"`
+	Title       string `scraper:"meta[name='twitter:title'], meta[property='twitter:title']" scrapeType:"attr:content"`
+	Description string `scraper:"meta[name='twitter:description'], meta[property='twitter:description']" scrapeType:"attr:content"`
+	Image       string `scraper:"meta[name='twitter:image'], meta[property='twitter:image']" scrapeType:"attr:content"`
+}
+
+type twitterCard TwitterCard
+
+// UnmarshalHTML assigns the Twitter card meta tags found within n
+func (tc *TwitterCard) UnmarshalHTML(n *html.Node) error {
+	return unmarshalMeta(n, (*twitterCard)(tc))
+}
+
+// Alternate is an alternate language version of a page, as given by
+// a <link rel="alternate" hreflang="..."> element
+type Alternate struct {
+	Hreflang string `scraper:":scope" scrapeType:"attr:hreflang"`
+	Href     string `scraper:":scope" scrapeType:"attr:href"`
+}
+
+// PageMeta holds the common metadata of a page: its title, canonical URL,
+// description, robots directives and alternate language versions
+type PageMeta struct {
+	Title       string      `scraper:"title"`
+	Canonical   string      `scraper:"link[rel='canonical']" scrapeType:"attr:href"`
+	Description string      `scraper:"meta[name='description']" scrapeType:"attr:content"`
+	Robots      string      `scraper:"meta[name='robots']" scrapeType:"attr:content"`
+	Alternates  []Alternate `scraper:"link[rel='alternate'][hreflang]"`
+}
+
+type pageMeta PageMeta
+
+// UnmarshalHTML assigns the metadata found within n
+func (pm *PageMeta) UnmarshalHTML(n *html.Node) error {
+	return unmarshalMeta(n, (*pageMeta)(pm))
+}
+
+// unmarshalMeta unmarshals the head element n into v, ignoring
+// surrounding whitespace in the values
+func unmarshalMeta(n *html.Node, v interface{}) error {
+	return NewUnmarshaler(n, TrimSpace()).Unmarshal(v)
+}
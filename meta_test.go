@@ -0,0 +1,76 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+type metaTest struct {
+	OpenGraph OpenGraph    `scraper:"head"`
+	Twitter   *TwitterCard `scraper:"head"`
+	Meta      PageMeta     `scraper:"head"`
+}
+
+func TestMeta(t *testing.T) {
+	input := `<html><head>
+		<title>
+			Widget | Acme
+		</title>
+		<meta name="description" content="The best widget">
+		<meta name="robots" content="noindex, follow">
+		<link rel="canonical" href="https://example.com/widget">
+		<link rel="alternate" hreflang="de" href="https://example.com/de/widget">
+		<link rel="alternate" hreflang="fr" href="https://example.com/fr/widget">
+		<link rel="alternate" type="application/rss+xml" href="/feed">
+		<meta property="og:title" content="Widget">
+		<meta property="og:type" content="product">
+		<meta property="og:url" content="https://example.com/widget">
+		<meta property="og:image" content="https://example.com/1.png">
+		<meta property="og:image" content="https://example.com/2.png">
+		<meta name="twitter:card" content="summary">
+		<meta property="twitter:site" content="@acme">
+	</head><body><meta property="og:title" content="Ignored"></body></html>`
+
+	got := &metaTest{}
+	if err := Unmarshal([]byte(input), got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := &metaTest{
+		OpenGraph: OpenGraph{
+			Title:  "Widget",
+			Type:   "product",
+			URL:    "https://example.com/widget",
+			Images: []string{"https://example.com/1.png", "https://example.com/2.png"},
+		},
+		Twitter: &TwitterCard{Card: "summary", Site: "@acme"},
+		Meta: PageMeta{
+			Title:       "Widget | Acme",
+			Canonical:   "https://example.com/widget",
+			Description: "The best widget",
+			Robots:      "noindex, follow",
+			Alternates: []Alternate{
+				{"de", "https://example.com/de/widget"},
+				{"fr", "https://example.com/fr/widget"},
+			},
+		},
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// ErrNoMicrodata indicates that the document does not contain
+	// any microdata items of the requested type
+	ErrNoMicrodata = errors.New("No matching microdata found")
+
+	// itemPlanCache maps a struct reflect.Type to its itemPlan
+	itemPlanCache sync.Map
+)
+
+// ItemPropTagName (itemprop) is the struct field tag used to name the microdata
+// property that is assigned to a field when unmarshaling microdata
+const ItemPropTagName = "itemprop"
+
+// itemPlan maps microdata property names to the indexes of the
+// struct fields that receive them
+type itemPlan map[string][]int
+
+func cachedItemPlan(t reflect.Type) itemPlan {
+	if p, found := itemPlanCache.Load(t); found {
+		return p.(itemPlan)
+	}
+
+	p := make(itemPlan)
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		if name, found := ft.Tag.Lookup(ItemPropTagName); found && ft.PkgPath == "" {
+			p[name] = append(p[name], i)
+		}
+	}
+	actual, _ := itemPlanCache.LoadOrStore(t, p)
+	return actual.(itemPlan)
+}
+
+// UnmarshalMicrodata unmarshals the HTML microdata items found in root into v.
+// See Unmarshaler.UnmarshalMicrodata for details
+func UnmarshalMicrodata(root *html.Node, itemtype string, v interface{}) error {
+	return NewUnmarshaler(root).UnmarshalMicrodata(itemtype, v)
+}
+
+// UnmarshalMicrodata unmarshals the top level microdata items (elements with an
+// itemscope attribute but no itemprop attribute) whose itemtype matches itemtype
+// into v.  Types match either exactly or by their final component, so "Product"
+// matches "https://schema.org/Product", and an empty itemtype matches every item.
+// When v is a pointer to a slice, each matching item is appended to the slice.
+// Otherwise, v must point to a struct and the first matching item is used.
+// ErrNoMicrodata is returned when nothing matches.
+//
+// Struct fields are assigned the properties named by their itemprop tag, using the
+// value rules of the microdata specification: the content attribute of meta, the
+// href of a, area and link, the src of media elements, the datetime of time and so
+// on.  URL values are resolved against the document's base.  Struct fields receive
+// nested items and slice fields receive every value of a property, while other
+// fields receive the first value.  Properties found via itemref are included
+func (u *Unmarshaler) UnmarshalMicrodata(itemtype string, v interface{}) error {
+	if u.err != nil {
+		return u.err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidUnmarshalError{reflect.TypeOf(v), reflect.Ptr}
+	}
+
+	items := u.findItems(itemtype)
+	if len(items) == 0 {
+		return ErrNoMicrodata
+	}
+
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Slice {
+		items = items[:1]
+	}
+
+	var errs UnmarshalErrors
+	f := &field{Value: rv, tag: &tag{}}
+	for _, item := range items {
+		err := u.unmarshalItemValue(f, item)
+		if err != nil && !u.continueOnError {
+			setStruct(err, leafType(rv.Type()).Name())
+			return err
+		}
+		errs = errs.append(err)
+	}
+
+	err := errs.err()
+	setStruct(err, leafType(rv.Type()).Name())
+	return err
+}
+
+// findItems returns the top level items of the given type in document order
+func (u *Unmarshaler) findItems(itemtype string) (items []*selection) {
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			s := &selection{n}
+			_, scope := s.lookupAttr("itemscope")
+			_, prop := s.lookupAttr("itemprop")
+			if scope && !prop && (itemtype == "" || hasItemType(s.attr("itemtype"), itemtype)) {
+				items = append(items, s)
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(u.root)
+	return items
+}
+
+func hasItemType(itemtypes, itemtype string) bool {
+	for _, t := range strings.Fields(itemtypes) {
+		if schemaTypeMatches(t, itemtype) {
+			return true
+		}
+	}
+	return false
+}
+
+// unmarshalItemValue assigns the item to f, appending to slices
+// and allocating pointers as necessary
+func (u *Unmarshaler) unmarshalItemValue(f *field, item *selection) (err error) {
+	switch f.Kind() {
+	case reflect.Slice:
+		elem := reflect.New(f.Type().Elem()).Elem()
+		newField := &field{Value: elem, tag: f.tag, path: f.path + "[" + strconv.Itoa(f.Len()) + "]"}
+		if err = u.unmarshalItemValue(newField, item); err == nil {
+			f.Set(reflect.Append(f.Value, elem))
+		}
+	case reflect.Ptr:
+		if f.IsNil() {
+			f.Set(reflect.New(f.Type().Elem()))
+		}
+		err = u.unmarshalItemValue(&field{Value: f.Elem(), tag: f.tag, path: f.path}, item)
+	case reflect.Struct:
+		err = u.unmarshalItem(f, item)
+	default:
+		err = &UnsupportedTypeError{Field: f.path, Type: f.Type()}
+	}
+	return err
+}
+
+// unmarshalItem assigns the properties of item to the struct field f
+func (u *Unmarshaler) unmarshalItem(f *field, item *selection) error {
+	plan := cachedItemPlan(f.Type())
+	assigned := make(map[int]bool)
+
+	var errs UnmarshalErrors
+	for _, prop := range u.itemProperties(item) {
+		for _, name := range strings.Fields(prop.attr("itemprop")) {
+			for _, i := range plan[name] {
+				child := &field{Value: f.Field(i), tag: &tag{}, path: joinPath(f.path, f.Type().Field(i).Name)}
+				if !child.multiple() {
+					if assigned[i] {
+						continue
+					}
+					assigned[i] = true
+				}
+
+				if err := u.unmarshalProperty(child, prop); err != nil {
+					if !u.continueOnError {
+						return err
+					}
+					errs = errs.append(err)
+				}
+			}
+		}
+	}
+	return errs.err()
+}
+
+// unmarshalProperty assigns the value of the property element n to f
+func (u *Unmarshaler) unmarshalProperty(f *field, n *selection) (err error) {
+	switch {
+	case f.Kind() == reflect.Slice && f.multiple():
+		elem := reflect.New(f.Type().Elem()).Elem()
+		newField := &field{Value: elem, tag: f.tag, path: f.path + "[" + strconv.Itoa(f.Len()) + "]"}
+		if err = u.unmarshalProperty(newField, n); err == nil {
+			f.Set(reflect.Append(f.Value, elem))
+		}
+	case f.Kind() == reflect.Ptr:
+		if f.IsNil() {
+			f.Set(reflect.New(f.Type().Elem()))
+		}
+		err = u.unmarshalProperty(&field{Value: f.Elem(), tag: f.tag, path: f.path}, n)
+	case f.Type() == timeType:
+		err = u.parseTime(f, u.propertyValue(n), dateTimeLayouts)
+	case f.Kind() == reflect.Struct && !implementsUnmarshaler(f.Type()):
+		// text values are not assigned to struct fields
+		if _, isItem := n.lookupAttr("itemscope"); isItem {
+			err = u.unmarshalItem(f, n)
+		}
+	default:
+		var value string
+		if value, err = u.process(f.tag, u.propertyValue(n)); err != nil {
+			break
+		}
+
+		if err = u.tryUnmarshalText(f, value); err == errNoUnmarshaler {
+			err = f.set(value)
+		}
+	}
+	return f.annotate(err)
+}
+
+// tryUnmarshalText unmarshals value into f if f
+// implements TextUnmarshaler or BinaryUnmarshaler
+func (u *Unmarshaler) tryUnmarshalText(f *field, value string) error {
+	if f.CanAddr() && f.CanInterface() {
+		switch i := f.Addr().Interface().(type) {
+		case TextUnmarshaler:
+			return i.UnmarshalText([]byte(value))
+		case BinaryUnmarshaler:
+			return i.UnmarshalBinary([]byte(value))
+		}
+	}
+	return errNoUnmarshaler
+}
+
+// itemProperties returns the property elements of item, including those
+// referenced by the item's itemref attribute.  The properties of nested
+// items are not included
+func (u *Unmarshaler) itemProperties(item *selection) (props []*selection) {
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+
+			s := &selection{c}
+			if _, found := s.lookupAttr("itemprop"); found {
+				props = append(props, s)
+			}
+
+			if _, found := s.lookupAttr("itemscope"); !found {
+				f(c)
+			}
+		}
+	}
+
+	f(item.Node)
+	for _, id := range strings.Fields(item.attr("itemref")) {
+		if ref := findByID(u.root, id); ref != nil {
+			s := &selection{ref}
+			if _, found := s.lookupAttr("itemprop"); found {
+				props = append(props, s)
+			}
+
+			if _, found := s.lookupAttr("itemscope"); !found {
+				f(ref)
+			}
+		}
+	}
+	return props
+}
+
+// findByID returns the first element within root with the given id
+func findByID(root *html.Node, id string) *html.Node {
+	if root.Type == html.ElementNode && (&selection{root}).attr("id") == id {
+		return root
+	}
+
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if n := findByID(c, id); n != nil {
+			return n
+		}
+	}
+	return nil
+}
+
+// propertyValue returns the value of the property element n following
+// the microdata specification's rules.  URL property values are resolved
+// against the document's base
+func (u *Unmarshaler) propertyValue(n *selection) string {
+	switch n.DataAtom {
+	case atom.Meta:
+		return n.attr("content")
+	case atom.Audio, atom.Embed, atom.Iframe, atom.Img, atom.Source, atom.Track, atom.Video:
+		return u.resolve(n.attr("src"))
+	case atom.A, atom.Area, atom.Link:
+		return u.resolve(n.attr("href"))
+	case atom.Object:
+		return u.resolve(n.attr("data"))
+	case atom.Data, atom.Meter:
+		return n.attr("value")
+	case atom.Time:
+		if datetime, found := n.lookupAttr("datetime"); found {
+			return datetime
+		}
+	}
+	return n.text()
+}
@@ -0,0 +1,155 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"golang.org/x/net/html"
+)
+
+const microdataTestDocument = `<html><body>
+<div itemscope itemtype="https://schema.org/Product" itemref="brand">
+	<h1 itemprop="name">Widget</h1>
+	<img itemprop="image" src="/widget.png">
+	<img itemprop="image" src="/widget-2.png">
+	<meta itemprop="sku" content="W-1">
+	<a itemprop="url" href="/p/widget">Widget</a>
+	<time itemprop="releaseDate" datetime="2019-05-01">May 1st</time>
+	<div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+		<span itemprop="price">9.99</span>
+		<meta itemprop="name" content="Offer name">
+	</div>
+	<span itemprop="rating">4</span>
+</div>
+<p id="brand" itemprop="brand">Acme</p>
+<div itemscope itemtype="https://schema.org/Product"><span itemprop="name">Gadget</span></div>
+<div itemscope itemtype="https://schema.org/Person"><span itemprop="name">Jane</span></div>
+</body></html>`
+
+type microdataTestOffer struct {
+	Price float64 `itemprop:"price"`
+	Name  string  `itemprop:"name"`
+}
+
+type microdataTestProduct struct {
+	Name        string               `itemprop:"name"`
+	Brand       string               `itemprop:"brand"`
+	Images      []string             `itemprop:"image"`
+	SKU         string               `itemprop:"sku"`
+	URL         string               `itemprop:"url"`
+	ReleaseDate time.Time            `itemprop:"releaseDate"`
+	Offers      []microdataTestOffer `itemprop:"offers"`
+	Rating      *int                 `itemprop:"rating"`
+}
+
+func parseMicrodataTest(t *testing.T) *html.Node {
+	root, err := html.Parse(strings.NewReader(microdataTestDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+	return root
+}
+
+func TestUnmarshalMicrodata(t *testing.T) {
+	root := parseMicrodataTest(t)
+	rating := 4
+
+	got := &microdataTestProduct{}
+	if err := UnmarshalMicrodata(root, "Product", got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := &microdataTestProduct{
+		Name:        "Widget",
+		Brand:       "Acme",
+		Images:      []string{"/widget.png", "/widget-2.png"},
+		SKU:         "W-1",
+		URL:         "/p/widget",
+		ReleaseDate: time.Date(2019, time.May, 1, 0, 0, 0, 0, time.UTC),
+		Offers:      []microdataTestOffer{{9.99, "Offer name"}},
+		Rating:      &rating,
+	}
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestUnmarshalMicrodataBaseURL(t *testing.T) {
+	root := parseMicrodataTest(t)
+	base, _ := url.Parse("https://example.com/products/")
+
+	got := &microdataTestProduct{}
+	if err := NewUnmarshaler(root, BaseURL(base)).UnmarshalMicrodata("Product", got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	wantImages := []string{"https://example.com/widget.png", "https://example.com/widget-2.png"}
+	if diff := deep.Equal(wantImages, got.Images); diff != nil {
+		t.Error(diff)
+	}
+
+	if got.URL != "https://example.com/p/widget" {
+		t.Errorf("Wanted resolved URL got %q", got.URL)
+	}
+
+	if got.SKU != "W-1" {
+		t.Errorf("Wanted unresolved SKU got %q", got.SKU)
+	}
+}
+
+func TestUnmarshalMicrodataSlice(t *testing.T) {
+	root := parseMicrodataTest(t)
+
+	var got []*struct {
+		Name string `itemprop:"name"`
+	}
+	if err := UnmarshalMicrodata(root, "https://schema.org/Product", &got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if len(got) != 2 || got[0].Name != "Widget" || got[1].Name != "Gadget" {
+		t.Errorf("Wanted Widget and Gadget got %+v", got)
+	}
+
+	var all []struct{}
+	if err := UnmarshalMicrodata(root, "", &all); err != nil || len(all) != 3 {
+		t.Errorf("Wanted 3 items got %d (%v)", len(all), err)
+	}
+}
+
+func TestUnmarshalMicrodataErrors(t *testing.T) {
+	root := parseMicrodataTest(t)
+
+	if err := UnmarshalMicrodata(root, "Recipe", &microdataTestProduct{}); err != ErrNoMicrodata {
+		t.Errorf("Wanted error %v got %v", ErrNoMicrodata, err)
+	}
+
+	if _, ok := UnmarshalMicrodata(root, "Product", nil).(*InvalidUnmarshalError); !ok {
+		t.Errorf("Wanted *InvalidUnmarshalError")
+	}
+
+	v := &struct {
+		SKU int `itemprop:"sku"`
+	}{}
+	err := UnmarshalMicrodata(root, "Product", v)
+	if ute, ok := err.(*UnmarshalTypeError); !ok || ute.Field != "SKU" {
+		t.Errorf("Wanted *UnmarshalTypeError for SKU got %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"reflect"
+	"sync"
+)
+
+// planCache maps a struct reflect.Type to its *structPlan
+var planCache sync.Map
+
+// structPlan is the pre-computed unmarshaling plan for a struct type.  Plans
+// are built once per type and then shared, so they must never be modified
+// after they have been stored in the cache
+type structPlan struct {
+	fields []fieldPlan
+}
+
+// fieldPlan is the compiled tag for a single struct field
+type fieldPlan struct {
+	index int
+	name  string
+	tag   *tag
+}
+
+var (
+	textUnmarshalerType   = reflect.TypeOf((*TextUnmarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*BinaryUnmarshaler)(nil)).Elem()
+	htmlUnmarshalerType   = reflect.TypeOf((*HTMLUnmarshaler)(nil)).Elem()
+)
+
+// cachedPlan returns the plan for the struct type t, building it the first
+// time the type is seen
+func cachedPlan(t reflect.Type) (*structPlan, error) {
+	return loadPlan(t, make(map[reflect.Type]bool))
+}
+
+// loadPlan returns the cached plan for t or builds it.  Nested struct types
+// are planned eagerly so that unsupported field types are reported before
+// any document is walked.  visiting holds the types currently being built
+// in order to stop recursive types from looping forever
+func loadPlan(t reflect.Type, visiting map[reflect.Type]bool) (*structPlan, error) {
+	if p, found := planCache.Load(t); found {
+		return p.(*structPlan), nil
+	}
+
+	visiting[t] = true
+	p, err := buildPlan(t, visiting)
+	delete(visiting, t)
+	if err == nil {
+		actual, _ := planCache.LoadOrStore(t, p)
+		p = actual.(*structPlan)
+	}
+	return p, err
+}
+
+func buildPlan(rt reflect.Type, visiting map[reflect.Type]bool) (p *structPlan, err error) {
+	p = &structPlan{}
+	for i := 0; i < rt.NumField(); i++ {
+		ft := rt.Field(i)
+		var t *tag
+		if t, err = parseTag(ft); err == nil {
+			t.setConverters(ft.Type)
+			if err = checkType(leafType(ft.Type), t, visiting); err != nil {
+				if ute, ok := err.(*UnsupportedTypeError); ok && ute.Field == "" {
+					ute.Field = joinPath(rt.Name(), ft.Name)
+				}
+				break
+			}
+			p.fields = append(p.fields, fieldPlan{index: i, name: ft.Name, tag: t})
+		} else if err == errNoTag {
+			err = nil
+		} else {
+			break
+		}
+	}
+	return p, err
+}
+
+// checkType determines whether values of type t can be unmarshaled using
+// the given tag
+func checkType(t reflect.Type, tg *tag, visiting map[reflect.Type]bool) (err error) {
+	if tg.typ == embeddedJSON {
+		// encoding/json reports its own type errors
+		return nil
+	}
+
+	if tg.typ == attrs {
+		if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String || tg.conv == nil {
+			return &UnsupportedTypeError{Type: t}
+		}
+		return nil
+	}
+
+	if implementsUnmarshaler(t) || tg.conv != nil {
+		return nil
+	}
+
+	if t.Kind() == reflect.Map && tg.key != nil {
+		if tg.key.conv == nil {
+			return &UnsupportedTypeError{Type: t.Key()}
+		}
+		return checkType(leafType(t.Elem()), tg.value, visiting)
+	}
+
+	if t.Kind() == reflect.Struct {
+		if !visiting[t] {
+			_, err = loadPlan(t, visiting)
+		}
+		return err
+	}
+	return &UnsupportedTypeError{Type: t}
+}
+
+// implementsUnmarshaler reports whether t, or a pointer to t, implements
+// any of the unmarshaler interfaces
+func implementsUnmarshaler(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	for _, it := range []reflect.Type{textUnmarshalerType, binaryUnmarshalerType, htmlUnmarshalerType} {
+		if t.Implements(it) || pt.Implements(it) {
+			return true
+		}
+	}
+	return false
+}
+
+// setConverters assigns the converters for a field of type ft
+func (t *tag) setConverters(ft reflect.Type) {
+	leaf := leafType(ft)
+	t.conv = converterFor(leaf)
+	t.boolAttr = t.typ == attr && leaf.Kind() == reflect.Bool
+	if leaf.Kind() == reflect.Map && t.typ == attrs {
+		t.conv = converterFor(leaf.Elem())
+	} else if leaf.Kind() == reflect.Map && t.key != nil {
+		t.key.conv = converterFor(leaf.Key())
+		t.value.setConverters(leaf.Elem())
+	}
+}
+
+// leafType strips away any pointers and slices to find the
+// type that values will actually be converted into.  Slice types
+// that implement an unmarshaler, such as net.IP, are leaves
+func leafType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || (t.Kind() == reflect.Slice && !implementsUnmarshaler(t)) {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+type planTest struct {
+	Name    string `scraper:".name"`
+	Skipped string
+	Count   []*int `scraper:".count"`
+}
+
+type planTestBadSelector struct {
+	Name string `scraper:"[[["`
+}
+
+type planTestBadType struct {
+	Name string `scraper:".name" scrapeType:"foo"`
+}
+
+type planTestUnsupported struct {
+	Tags map[string]int `scraper:".tags"`
+}
+
+type planTestNestedUnsupported struct {
+	Inner planTestUnsupported `scraper:".inner"`
+}
+
+type planTestRecursive struct {
+	Name     string               `scraper:".name"`
+	Children []*planTestRecursive `scraper:".child"`
+	Nested   planTestNested       `scraper:".nested"`
+}
+
+type planTestNested struct {
+	Unmarshaler testTextUnmarshaler `scraper:".text"`
+	Parent      *planTestRecursive  `scraper:".parent"`
+}
+
+type planTestMap struct {
+	Specs map[string][]int `scraper:"tr" scrapeKey:"th" scrapeValue:"td"`
+}
+
+type planTestMapKey struct {
+	Specs map[struct{}]string `scraper:"tr" scrapeKey:"th" scrapeValue:"td"`
+}
+
+type planTestAttrs struct {
+	Attrs string `scraper:"div" scrapeType:"attrs"`
+}
+
+func TestCachedPlan(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       reflect.Type
+		wantIndexes []int
+		wantErr     bool
+	}{
+		{"skips untagged fields", reflect.TypeOf(planTest{}), []int{0, 2}, false},
+		{"bad selector", reflect.TypeOf(planTestBadSelector{}), nil, true},
+		{"bad type", reflect.TypeOf(planTestBadType{}), nil, true},
+		{"map", reflect.TypeOf(planTestMap{}), []int{0}, false},
+		{"recursive type", reflect.TypeOf(planTestRecursive{}), []int{0, 1, 2}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := cachedPlan(test.input)
+			if test.wantErr {
+				if gotErr == nil {
+					t.Errorf("Wanted an error")
+				}
+				return
+			} else if gotErr != nil {
+				t.Fatalf("Unexpected error: %v", gotErr)
+			}
+
+			gotIndexes := []int{}
+			for _, fp := range got.fields {
+				gotIndexes = append(gotIndexes, fp.index)
+			}
+
+			if !reflect.DeepEqual(test.wantIndexes, gotIndexes) {
+				t.Errorf("Wanted field indexes %v got %v", test.wantIndexes, gotIndexes)
+			}
+
+			if again, _ := cachedPlan(test.input); again != got {
+				t.Errorf("Wanted the cached plan to be reused")
+			}
+		})
+	}
+}
+
+type planTestItem struct {
+	Label string `scraper:":scope > span"`
+}
+
+type planTestConcurrent struct {
+	Name  string            `scraper:".title || h1" scrapeFilter:"trim|upper"`
+	Price float64           `scraper:".price" scrapeRegex:"([0-9.]+)"`
+	Link  string            `scraper:"a" scrapeType:"url"`
+	Specs map[string]string `scraper:"tr" scrapeKey:"th" scrapeValue:"td"`
+	Items []planTestItem    `scraper:"li"`
+}
+
+func TestCachedPlanConcurrent(t *testing.T) {
+	input := `<h1> widget </h1><span class="price">$9.99</span><a href="/p/1">P</a>
+		<table><tr><th>Color</th><td>Red</td></tr></table>
+		<ul><li><span>one</span></li><li><span>two</span></li></ul>`
+	want := &planTestConcurrent{
+		Name:  "WIDGET",
+		Price: 9.99,
+		Link:  "https://example.com/p/1",
+		Specs: map[string]string{"Color": "Red"},
+		Items: []planTestItem{{"one"}, {"two"}},
+	}
+
+	base, _ := url.Parse("https://example.com/")
+	for i := 0; i < 8; i++ {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Parallel()
+			root, err := html.Parse(strings.NewReader(input))
+			if err != nil {
+				t.Fatalf("Failed to parse html: %v", err)
+			}
+
+			got := &planTestConcurrent{}
+			if err := NewUnmarshaler(root, BaseURL(base)).Unmarshal(got); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("Wanted %+v got %+v", want, got)
+			}
+		})
+	}
+}
+
+func TestLeafType(t *testing.T) {
+	tests := []struct {
+		name  string
+		input reflect.Type
+		want  reflect.Type
+	}{
+		{"scalar", reflect.TypeOf(0), reflect.TypeOf(0)},
+		{"pointer", reflect.TypeOf(new(int)), reflect.TypeOf(0)},
+		{"slice of pointers", reflect.TypeOf([]*string{}), reflect.TypeOf("")},
+		{"unmarshaler slice", reflect.TypeOf([]net.IP{}), reflect.TypeOf(net.IP{})},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := leafType(test.input)
+			if test.want != got {
+				t.Errorf("Wanted type %v got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestCachedPlanUnsupported(t *testing.T) {
+	tests := []struct {
+		name  string
+		input reflect.Type
+		want  *UnsupportedTypeError
+	}{
+		{"map", reflect.TypeOf(planTestUnsupported{}), &UnsupportedTypeError{"planTestUnsupported.Tags", reflect.TypeOf(map[string]int{})}},
+		{"nested map", reflect.TypeOf(planTestNestedUnsupported{}), &UnsupportedTypeError{"planTestUnsupported.Tags", reflect.TypeOf(map[string]int{})}},
+		{"attrs", reflect.TypeOf(planTestAttrs{}), &UnsupportedTypeError{"planTestAttrs.Attrs", reflect.TypeOf("")}},
+		{"map key", reflect.TypeOf(planTestMapKey{}), &UnsupportedTypeError{"planTestMapKey.Specs", reflect.TypeOf(struct{}{})}},
+		{"anonymous struct", reflect.TypeOf(struct {
+			X interface{} `scraper:"p"`
+		}{}), &UnsupportedTypeError{"X", reflect.TypeOf((*interface{})(nil)).Elem()}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, gotErr := cachedPlan(test.input)
+			if got, ok := gotErr.(*UnsupportedTypeError); ok {
+				if *test.want != *got {
+					t.Errorf("Wanted error %v got %v", test.want, got)
+				}
+			} else {
+				t.Errorf("Wanted *UnsupportedTypeError got %v", gotErr)
+			}
+		})
+	}
+}
@@ -59,6 +59,139 @@
 //		}
 // Note that the attribute name is specified after the type (attr) and a separating colon.
 //
+// The raw markup of a matching element can be captured with the "html" type (the
+// element's children) or the "outerhtml" type (the element itself):
+//		type Article struct {
+//			Body string `scraper:"article" scrapeType:"html"`
+//		}
+//
+// Boolean fields are parsed with strconv.ParseBool, additionally accepting the words
+// "yes", "on", "checked" and "selected" (and "no" and "off").  Like HTML boolean
+// attributes, an attribute assigned to a bool field is true when it has no value or its
+// own name, as in <input checked> or <input disabled="disabled">, and false when it is
+// absent.  The "exists" type sets a field to true whenever the selector matches anything:
+//		type Product struct {
+//			InStock bool `scraper:".badge-in-stock" scrapeType:"exists"`
+//		}
+//
+// The layouts for time.Time fields are given, in the form expected by time.Parse, with the
+// "scrapeTime" tag.  Alternative layouts are separated by a pipe (|) and are tried in order.
+// HTML time elements with a datetime attribute use the attribute in place of the text content:
+//		type Post struct {
+//			Published time.Time `scraper:".published" scrapeTime:"January 2, 2006|2006-01-02"`
+//			Updated   time.Time `scraper:"time.updated"`
+//		}
+//
+// A field with the "required" option in its "scrapeOptions" tag causes a NoMatchError
+// when its selector does not match any elements.  The Strict option makes every field
+// required except for those with the "optional" option:
+//		type Product struct {
+//			Name  string `scraper:"h1" scrapeOptions:"required"`
+//			Notes string `scraper:".notes" scrapeOptions:"optional"`
+//		}
+//
+// The fields of a nested struct are matched against the subtree of the element that
+// matched the struct's own selector.  Combinators never look beyond that element, and
+// the element itself can only be referred to with the :scope pseudo-class, just as with
+// querySelectorAll.  A selector that begins with a combinator is relative to :scope:
+//		type List struct {
+//			Items []struct {
+//				Name     string   `scraper:":scope > span"`
+//				Children []string `scraper:"> ul > li"`
+//			} `scraper:"li"`
+//		}
+//
+// Once an element matches, its descendants are not searched for further matches.  The
+// "all" option (or the Traverse option with AllMatches) collects every match in document
+// order instead, so nested list items are also found:
+//		type Outline struct {
+//			Items []string `scraper:"li" scrapeOptions:"all"`
+//		}
+//
+// Fields that are not slices are assigned the first matching element.  The "last" and
+// "index:n" options choose a different match, where negative indexes count back from the
+// last match, and the "unique" option returns a MultipleMatchError if more than one
+// element matches:
+//		type Page struct {
+//			Title    string `scraper:"h1" scrapeOptions:"unique"`
+//			Footnote string `scraper:".note" scrapeOptions:"last"`
+//		}
+//
+// The NormalizeSpace option, or the "normalize" option for a single field, collapses runs
+// of whitespace like a browser does.  The "innertext" type keeps the paragraph structure,
+// placing block elements and <br> on separate lines:
+//		type Article struct {
+//			Title string `scraper:"h1" scrapeOptions:"normalize"`
+//			Body  string `scraper:".content" scrapeType:"innertext"`
+//		}
+//
+// The "visibletext" type skips the contents of script, style, noscript and template
+// elements, which keeps broad selectors from picking up code.  With the "skiphidden"
+// option, elements hidden by the hidden, aria-hidden or an inline display:none style
+// are skipped too:
+//		type Article struct {
+//			Body string `scraper:"article" scrapeType:"visibletext" scrapeOptions:"skiphidden,normalize"`
+//		}
+//
+// The "scrapeRegex" tag extracts part of a value with a regular expression before it is
+// assigned.  The capture group named "value" is used, or else the first capture group,
+// or else the entire match.  A RegexMismatchError is returned if the expression does
+// not match:
+//		type Product struct {
+//			Price float64 `scraper:".price" scrapeRegex:"\\$([\\d.]+)"`
+//		}
+//
+// The "scrapeFilter" tag transforms values with a pipeline of filters, such as "trim",
+// "lower", "collapse" and "replace", which run after any regular expression.  Custom
+// filters are registered with the Filter option:
+//		type Product struct {
+//			Price float64 `scraper:".price" scrapeFilter:"trim|replace:,:|prefix:$"`
+//		}
+//
+// Alternative selectors, separated by "||", are tried in order until one of them yields
+// a non-empty value, which helps when a site serves more than one layout.  The
+// ReportAlternatives option reports the alternative that was used for each field:
+//		type Product struct {
+//			Price string `scraper:".price-new || #price span"`
+//		}
+//
+// Map fields receive an entry for each element matching the "scraper" selector.  The
+// "scrapeKey" and "scrapeValue" selectors locate the key and value within that element
+// (an empty selector being the element itself) and "scrapeKeyType" selects the key's
+// text or an attribute, just like "scrapeType" does for the value.  Selectors that
+// start with a sibling combinator can reach the elements following the entry:
+//		type Product struct {
+//			Specs map[string]string `scraper:"tr" scrapeKey:"th" scrapeValue:"td"`
+//			Terms map[string]string `scraper:"dl dt" scrapeKey:"" scrapeValue:"+ dd"`
+//		}
+//
+// The "attrs" type fills a map with the attributes of the matching element.  A prefix
+// following the type limits the map to the attributes starting with that prefix, which
+// is removed from the keys:
+//		type Product struct {
+//			Data map[string]string `scraper:"#product" scrapeType:"attrs:data-"`
+//		}
+//
+// The "json" type decodes the text of the matching element, usually a script, into
+// the field with encoding/json.  Following the type with a JavaScript variable decodes
+// the value assigned to that variable within the script:
+//		type Page struct {
+//			Product Product                `scraper:"script[type='application/ld+json']" scrapeType:"json"`
+//			State   map[string]interface{} `scraper:"script#state" scrapeType:"json:window.__INITIAL_STATE__"`
+//		}
+//
+// The "url" type resolves an attribute (href or src by default) against the base URL
+// given with the BaseURL option and any <base href> element in the document.  Values
+// assigned to url.URL fields are resolved the same way:
+//		type Product struct {
+//			Link  string   `scraper:"a.product" scrapeType:"url:href"`
+//			Image *url.URL `scraper:"img.main" scrapeType:"attr:src"`
+//		}
+//
+// Besides strings, booleans and numbers (including complex numbers), fields may be
+// url.URL, time.Duration (parsed with time.ParseDuration), big.Int, big.Float or big.Rat,
+// or pointers and slices of these types.
+//
 // Types that implement encoding.BinaryUnmarshaler or encoding.TextUnmarshaler are honored:
 //		type Name struct {
 //			First string
@@ -15,21 +15,72 @@
 package scraper
 
 import (
+	"bytes"
 	"strings"
 
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 )
 
+// nonRenderedElements are never displayed, so their text is not visible
+var nonRenderedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+}
+
+// blockElements are the elements that innerText places on their own lines
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Caption: true, atom.Dd: true, atom.Details: true, atom.Dialog: true,
+	atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Fieldset: true,
+	atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hgroup: true, atom.Hr: true, atom.Li: true,
+	atom.Main: true, atom.Nav: true, atom.Ol: true, atom.P: true,
+	atom.Pre: true, atom.Section: true, atom.Summary: true, atom.Table: true,
+	atom.Tr: true, atom.Ul: true,
+}
+
 type selection struct {
 	*html.Node
 }
 
 func (n *selection) value(t *tag) (value string) {
 	switch t.typ {
-	case text:
+	case text, embeddedJSON:
 		value = n.text()
+	case ownText:
+		value = n.ownText()
+	case innerText:
+		value = n.innerText(t.skipHidden)
+	case visibleText:
+		value = n.visibleText(t.skipHidden)
 	case attr:
-		value = n.attr(t.detail)
+		var found bool
+		value, found = n.lookupAttr(t.detail)
+		if t.boolAttr {
+			value = boolAttrValue(t.detail, value, found)
+		}
+	case innerHTML:
+		value = n.html()
+	case outerHTML:
+		value = n.outerHTML()
+	case exists:
+		value = "true"
+	case resolvedURL:
+		value = n.urlAttr(t.detail)
+	}
+	return value
+}
+
+// boolAttrValue returns the value of an attribute assigned to a bool field.
+// Like HTML boolean attributes, such as checked or disabled, an attribute is
+// true when it is present with no value or its own name and false when it is
+// absent.  Any other value is left for parseBool
+func boolAttrValue(name, value string, found bool) string {
+	if !found {
+		return "false"
+	} else if value == "" || strings.EqualFold(value, name) {
+		return "true"
 	}
 	return value
 }
@@ -57,11 +108,175 @@ func (n *selection) text() (val string) {
 	return buf.String()
 }
 
+// urlAttr returns the value of the named attribute or, when name is
+// empty, the href attribute or the src attribute
+func (n *selection) urlAttr(name string) string {
+	if name != "" {
+		return n.attr(name)
+	}
+
+	if href, found := n.lookupAttr("href"); found {
+		return href
+	}
+	return n.attr("src")
+}
+
+// visibleText gathers the text nodes of the selected subtree, except for
+// those within elements that are never rendered, such as scripts and styles.
+// When skipHidden is true, elements that are hidden are skipped as well
+func (n *selection) visibleText(skipHidden bool) string {
+	var buf strings.Builder
+
+	var f func(*html.Node)
+	f = func(c *html.Node) {
+		if invisible(c, skipHidden) {
+			return
+		}
+
+		if c.Type == html.TextNode {
+			buf.WriteString(c.Data)
+		}
+
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			f(child)
+		}
+	}
+
+	f(n.Node)
+	return buf.String()
+}
+
+// invisible determines if n is an element that is never rendered or, when
+// skipHidden is true, an element that is hidden by its hidden, aria-hidden
+// or inline style attribute
+func invisible(n *html.Node, skipHidden bool) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+
+	if nonRenderedElements[n.DataAtom] {
+		return true
+	}
+
+	if skipHidden {
+		s := &selection{n}
+		if _, found := s.lookupAttr("hidden"); found {
+			return true
+		}
+
+		if strings.EqualFold(strings.TrimSpace(s.attr("aria-hidden")), "true") {
+			return true
+		}
+
+		style := strings.ToLower(strings.Join(strings.Fields(s.attr("style")), ""))
+		for _, decl := range strings.Split(style, ";") {
+			if decl == "display:none" || decl == "display:none!important" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// innerText gathers the text of the selected node roughly as a browser
+// renders it.  Block elements and <br> start new lines, table cells are
+// separated by spaces and the whitespace within each line is normalized.
+// Like visibleText, elements that are not rendered are skipped
+func (n *selection) innerText(skipHidden bool) string {
+	var lines []string
+	var line strings.Builder
+	breakLine := func(force bool) {
+		text := normalizeSpace(line.String())
+		if text != "" || force {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	var f func(*html.Node)
+	f = func(c *html.Node) {
+		if invisible(c, skipHidden) {
+			return
+		}
+
+		switch {
+		case c.Type == html.TextNode:
+			line.WriteString(c.Data)
+		case c.Type == html.ElementNode && c.DataAtom == atom.Br:
+			breakLine(true)
+			return
+		case c.Type == html.ElementNode && blockElements[c.DataAtom]:
+			breakLine(false)
+			defer breakLine(false)
+		case c.Type == html.ElementNode && (c.DataAtom == atom.Td || c.DataAtom == atom.Th):
+			defer line.WriteString(" ")
+		}
+
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			f(child)
+		}
+	}
+
+	f(n.Node)
+	breakLine(false)
+
+	// explicit line breaks can leave blank lines at either end
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normalizeSpace collapses each run of whitespace, including non-breaking
+// spaces, into a single space and removes surrounding whitespace
+func normalizeSpace(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// ownText gathers only the text nodes that are immediate
+// children of the selected node
+func (n *selection) ownText() string {
+	var buf strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			buf.WriteString(c.Data)
+		}
+	}
+	return buf.String()
+}
+
 func (n *selection) attr(name string) (val string) {
+	val, _ = n.lookupAttr(name)
+	return
+}
+
+// lookupAttr returns the value of the named attribute and
+// whether the attribute was present on the selected node
+func (n *selection) lookupAttr(name string) (val string, found bool) {
 	for i, a := range n.Attr {
 		if a.Key == name {
 			val = n.Attr[i].Val
+			found = true
 		}
 	}
 	return
 }
+
+// html renders the children of the selected node
+func (n *selection) html() string {
+	var buf bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(&buf, c)
+	}
+	return buf.String()
+}
+
+// outerHTML renders the selected node, including its children
+func (n *selection) outerHTML() string {
+	var buf bytes.Buffer
+	html.Render(&buf, n.Node)
+	return buf.String()
+}
@@ -30,7 +30,20 @@ func TestSelectionValue(t *testing.T) {
 		want      string
 	}{
 		{"text", "<p>some value <strong>with emphasis</strong></p>", &tag{typ: text}, "some value with emphasis"},
+		{"owntext", "<p>42 <span>units</span></p>", &tag{typ: ownText}, "42 "},
 		{"attr", `<a href="/some/path/somewhere">Click Me!</a>`, &tag{typ: attr, detail: "href"}, "/some/path/somewhere"},
+		{"bool attr empty", `<input type="checkbox" checked>`, &tag{typ: attr, detail: "checked", boolAttr: true}, "true"},
+		{"bool attr name", `<input type="checkbox" disabled="disabled">`, &tag{typ: attr, detail: "disabled", boolAttr: true}, "true"},
+		{"bool attr absent", `<input type="checkbox">`, &tag{typ: attr, detail: "checked", boolAttr: true}, "false"},
+		{"bool attr value", `<div aria-hidden="false"></div>`, &tag{typ: attr, detail: "aria-hidden", boolAttr: true}, "false"},
+		{"html", "<p>some value <strong>with emphasis</strong></p>", &tag{typ: innerHTML}, "some value <strong>with emphasis</strong>"},
+		{"outerhtml", "<p>some value <strong>with emphasis</strong></p>", &tag{typ: outerHTML}, "<p>some value <strong>with emphasis</strong></p>"},
+		{"exists", "<p></p>", &tag{typ: exists}, "true"},
+		{"innertext", "<div>\n  Hello\n  <b>World</b>\u00a0! <p>Second <br>line</p><ul><li>one</li><li>two</li></ul><br>end<br></div>", &tag{typ: innerText}, "Hello World !\nSecond\nline\none\ntwo\n\nend"},
+		{"visibletext", `<article>Hello<script>var x = 1;</script><style>p{}</style><noscript>Enable JS</noscript><template><p>row</p></template> <span hidden>World</span></article>`, &tag{typ: visibleText}, "Hello World"},
+		{"visibletext skip hidden", `<article>Hello<span hidden> there</span><span aria-hidden="true"> ★</span><span style="color: red; DISPLAY: none"> secret</span><span style="display: block"> World</span></article>`, &tag{typ: visibleText, skipHidden: true}, "Hello World"},
+		{"innertext skip hidden", `<div><p>Hello</p><script>var x;</script><p style="display:none">Hidden</p><p>World</p></div>`, &tag{typ: innerText, skipHidden: true}, "Hello\nWorld"},
+		{"innertext table", "<table><tr><th>Color</th><td>Red</td></tr><tr><th>Size</th><td>M</td></tr></table>", &tag{typ: innerText}, "Color Red\nSize M"},
 	}
 
 	for _, test := range tests {
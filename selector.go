@@ -0,0 +1,287 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+var (
+	// ErrInvalidSelector indicates that a selector could not be parsed
+	ErrInvalidSelector = errors.New("Invalid selector ")
+)
+
+// scopePseudoClass matches the element that a selector is evaluated
+// relative to.  For a nested struct, this is the element matched by the
+// struct's selector.  At the top level it is the document itself
+const scopePseudoClass = ":scope"
+
+// selector is a CSS selector group that is evaluated relative to a scope
+// element.  Cascadia evaluates combinators against the entire ancestry of
+// a node, so the selector is split into its compound selectors and the
+// combinators are evaluated here, where they can stop at the scope
+type selector []complexSelector
+
+// complexSelector is a chain of compound selectors separated by
+// combinators, in the order they appear in the selector text
+type complexSelector []compoundSelector
+
+// compoundSelector is a single compound selector and the combinator
+// that joins it to the compound selector on its left
+type compoundSelector struct {
+	combinator byte
+	scope      bool
+	sel        cascadia.Selector
+}
+
+// alternative is one selector in an ordered list of fallbacks,
+// such as ".price-new || #price span"
+type alternative struct {
+	query    string
+	selector selector
+}
+
+// compileAlternatives parses a list of selectors separated by "||"
+func compileAlternatives(str string) (alts []alternative, err error) {
+	for _, query := range splitSelector(str, "||") {
+		var s selector
+		if s, err = compileSelector(query); err != nil {
+			return nil, err
+		}
+		alts = append(alts, alternative{query: query, selector: s})
+	}
+	return alts, nil
+}
+
+// compileSelector parses a selector group, such as "ul > li, :scope > p"
+func compileSelector(str string) (s selector, err error) {
+	for _, group := range splitSelector(str, ",") {
+		var cs complexSelector
+		if cs, err = compileComplex(group); err != nil {
+			return nil, err
+		}
+		s = append(s, cs)
+	}
+	return s, nil
+}
+
+func compileComplex(str string) (cs complexSelector, err error) {
+	// combinator is the combinator preceding the next
+	// compound selector, or zero if there isn't one
+	var combinator byte
+	for _, token := range tokenizeSelector(str) {
+		switch token {
+		case " ":
+			combinator = ' '
+		case ">", "+", "~":
+			if combinator != 0 {
+				return nil, ErrInvalidSelector
+			}
+
+			if len(cs) == 0 {
+				// a leading combinator is relative to the scope
+				cs = append(cs, compoundSelector{scope: true})
+			}
+			combinator = token[0]
+		default:
+			compound := compoundSelector{combinator: combinator}
+			if strings.Contains(token, scopePseudoClass) {
+				compound.scope = true
+				token = strings.Replace(token, scopePseudoClass, "", -1)
+			}
+
+			if token != "" {
+				if compound.sel, err = cascadia.Compile(token); err != nil {
+					return nil, err
+				}
+			}
+			cs = append(cs, compound)
+			combinator = 0
+		}
+	}
+
+	if len(cs) == 0 || combinator != 0 {
+		return nil, ErrInvalidSelector
+	}
+	return cs, nil
+}
+
+// splitSelector splits str at every top level occurrence of sep, ignoring
+// anything within quotes, brackets or parentheses
+func splitSelector(str, sep string) (fields []string) {
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(str); i++ {
+		switch c := str[i]; {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case depth == 0 && strings.HasPrefix(str[i:], sep):
+			fields = append(fields, strings.TrimSpace(str[start:i]))
+			start = i + len(sep)
+			i = start - 1
+		}
+	}
+	return append(fields, strings.TrimSpace(str[start:]))
+}
+
+// tokenizeSelector splits a complex selector into its compound selectors
+// and combinators.  Whitespace between two compound selectors becomes a
+// single " " token and any whitespace around other combinators is dropped
+func tokenizeSelector(str string) (tokens []string) {
+	depth := 0
+	var quote byte
+	start := 0
+	emit := func(end int) {
+		if start < end {
+			tokens = append(tokens, str[start:end])
+		}
+	}
+
+	for i := 0; i < len(str); i++ {
+		switch c := str[i]; {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case depth == 0 && (c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'):
+			emit(i)
+			if len(tokens) > 0 && !isCombinator(tokens[len(tokens)-1]) {
+				tokens = append(tokens, " ")
+			}
+			start = i + 1
+		case depth == 0 && (c == '>' || c == '+' || c == '~'):
+			emit(i)
+			if len(tokens) > 0 && tokens[len(tokens)-1] == " " {
+				tokens = tokens[:len(tokens)-1]
+			}
+			tokens = append(tokens, string(c))
+			start = i + 1
+		}
+	}
+	emit(len(str))
+
+	if len(tokens) > 0 && tokens[len(tokens)-1] == " " {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
+
+func isCombinator(token string) bool {
+	return token == " " || token == ">" || token == "+" || token == "~"
+}
+
+// Match determines if n matches the selector when evaluated relative
+// to scope.  Only ancestors up to and including scope are considered
+// by the descendant and child combinators.  Like querySelectorAll, the
+// scope itself only matches selectors ending in :scope
+func (s selector) Match(n, scope *html.Node) bool {
+	for _, cs := range s {
+		last := len(cs) - 1
+		if (n != scope || cs[last].scope) && cs.match(last, n, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// siblings determines if the selector can match elements that follow
+// the scope element, such as ":scope + dd" or "~ p"
+func (s selector) siblings() bool {
+	for _, cs := range s {
+		if len(cs) > 1 && cs[0].scope && (cs[1].combinator == '+' || cs[1].combinator == '~') {
+			return true
+		}
+	}
+	return false
+}
+
+// match determines if n matches the compound selector at index i
+// and the compound selectors to the left of it
+func (cs complexSelector) match(i int, n, scope *html.Node) bool {
+	if !cs[i].match(n, scope) {
+		return false
+	}
+
+	if i == 0 {
+		return true
+	}
+
+	switch cs[i].combinator {
+	case ' ':
+		for ; n != scope && n.Parent != nil; n = n.Parent {
+			if cs.match(i-1, n.Parent, scope) {
+				return true
+			}
+		}
+	case '>':
+		if n != scope && n.Parent != nil {
+			return cs.match(i-1, n.Parent, scope)
+		}
+	case '+':
+		if n != scope {
+			if sibling := prevElementSibling(n); sibling != nil {
+				return cs.match(i-1, sibling, scope)
+			}
+		}
+	case '~':
+		if n != scope {
+			for sibling := prevElementSibling(n); sibling != nil; sibling = prevElementSibling(sibling) {
+				if cs.match(i-1, sibling, scope) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (c *compoundSelector) match(n, scope *html.Node) bool {
+	if c.scope {
+		return n == scope && (c.sel == nil || c.sel.Match(n))
+	}
+	return n.Type == html.ElementNode && c.sel.Match(n)
+}
+
+func prevElementSibling(n *html.Node) *html.Node {
+	for n = n.PrevSibling; n != nil; n = n.PrevSibling {
+		if n.Type == html.ElementNode {
+			return n
+		}
+	}
+	return nil
+}
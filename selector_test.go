@@ -0,0 +1,161 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"golang.org/x/net/html"
+)
+
+func TestTokenizeSelector(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"compound", "div.card", []string{"div.card"}},
+		{"descendant", "div  span", []string{"div", " ", "span"}},
+		{"child", "ul > li", []string{"ul", ">", "li"}},
+		{"no whitespace", "h1+p~p", []string{"h1", "+", "p", "~", "p"}},
+		{"leading combinator", "> li", []string{">", "li"}},
+		{"brackets", `a[title="a > b"] :nth-child(2n + 1)`, []string{`a[title="a > b"]`, " ", ":nth-child(2n + 1)"}},
+		{"trailing whitespace", "li ", []string{"li"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := tokenizeSelector(test.input)
+			if !reflect.DeepEqual(test.want, got) {
+				t.Errorf("Wanted tokens %q got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestCompileSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantLen int
+		wantErr bool
+	}{
+		{"simple", "li", 1, false},
+		{"group", "li, p > a", 2, false},
+		{"group in brackets", `a[title="a, b"]`, 1, false},
+		{"scope", ":scope > li", 1, false},
+		{"trailing combinator", "ul >", 0, true},
+		{"double combinator", "ul > + li", 0, true},
+		{"empty group", "li,", 0, true},
+		{"bad compound", "[[[", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := compileSelector(test.input)
+			if test.wantErr {
+				if gotErr == nil {
+					t.Errorf("Wanted an error")
+				}
+			} else if gotErr != nil {
+				t.Errorf("Unexpected error %v", gotErr)
+			} else if test.wantLen != len(got) {
+				t.Errorf("Wanted %d selectors got %d", test.wantLen, len(got))
+			}
+		})
+	}
+}
+
+func TestCompileAlternatives(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"single", ".price", []string{".price"}, false},
+		{"alternatives", ".price-new || #price span, .cost", []string{".price-new", "#price span, .cost"}, false},
+		{"quoted", `[title="a || b"] || p`, []string{`[title="a || b"]`, "p"}, false},
+		{"empty alternative", ".price ||", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, gotErr := compileAlternatives(test.input)
+			if test.wantErr {
+				if gotErr == nil {
+					t.Errorf("Wanted an error")
+				}
+				return
+			} else if gotErr != nil {
+				t.Fatalf("Unexpected error %v", gotErr)
+			}
+
+			var queries []string
+			for _, alt := range got {
+				queries = append(queries, alt.query)
+			}
+			if !reflect.DeepEqual(test.want, queries) {
+				t.Errorf("Wanted alternatives %q got %q", test.want, queries)
+			}
+		})
+	}
+}
+
+type scopeTest struct {
+	Card struct {
+		Spans    []string `scraper:"section span"`
+		Own      []string `scraper:"div span"`
+		Children []string `scraper:":scope > ul > li"`
+		Items    []string `scraper:"> ul > li"`
+		Adjacent []string `scraper:"h2 + p"`
+		Inner    string   `scraper:"div"`
+		Class    string   `scraper:":scope" scrapeType:"attr:class"`
+	} `scraper:".card"`
+}
+
+func TestScopedSelectors(t *testing.T) {
+	input := `<section>
+		<div class="card">
+			<span>one</span>
+			<ul><li>two<ul><li>three</li></ul></li></ul>
+			<h2>four</h2><p>five</p><p>six</p>
+			<div>seven</div>
+		</div>
+	</section>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	got := &scopeTest{}
+	if err := NewUnmarshaler(root).Unmarshal(got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := &scopeTest{}
+	want.Card.Own = []string{"one"}
+	want.Card.Children = []string{"twothree"}
+	want.Card.Items = []string{"twothree"}
+	want.Card.Adjacent = []string{"five"}
+	want.Card.Inner = "seven"
+	want.Card.Class = "card"
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Error(diff)
+	}
+}
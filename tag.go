@@ -16,18 +16,38 @@ package scraper
 
 import (
 	"errors"
+	"math/big"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
-
-	"github.com/andybalholm/cascadia"
+	"time"
 )
 
 var (
 	// ErrUnknownTagType indicates that the scraperType tag is an unknown value
 	ErrUnknownTagType = errors.New("Unknown tag type ")
 
+	// ErrUnknownTagOption indicates that the scrapeOptions tag contains an unknown option
+	ErrUnknownTagOption = errors.New("Unknown tag option ")
+
 	errNoTag = errors.New("No HTML Tag found")
+
+	durationType = reflect.TypeOf(time.Duration(0))
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
+
+	// typeConverters are the converters for standard library types.  They
+	// take precedence over the conversion for the type's kind and over any
+	// unmarshaler methods that the type implements
+	typeConverters = map[reflect.Type]converter{
+		durationType: setDuration,
+		urlType:      setURL,
+		bigIntType:   setBigInt,
+		bigFloatType: setBigFloat,
+		bigRatType:   setBigRat,
+	}
 )
 
 // Scraper uses struct field tags to determine how to unmarshal an HTML element tree into
@@ -39,14 +59,82 @@ var (
 //   }
 const (
 	// SelectorTagName is used to reflect the appropriate struct field tag.  The SelectorTagName
-	// is the tag used to specify a CSS selector to match for the field
+	// is the tag used to specify a CSS selector to match for the field.  Alternative selectors
+	// may be separated by `||`, in which case they are tried in order and the first one whose
+	// matches yield a non-empty value is used
 	SelectorTagName = "scraper"
 
 	// TypeTagName (scrapeType) is the tag used to specify what kind of value lookup should be performed.  The
 	// default is `text` and simply gathers the text nodes from the matching html subtree.  The
 	// alternative type is `attr` which will assign value based on a matching attribute.  The
-	// attribute name (for the matched node) is specified following a colon
+	// attribute name (for the matched node) is specified following a colon.  The `owntext` type
+	// only gathers the text nodes that are direct children of the matching element.  The
+	// `innertext` type gathers the text like a browser renders it, with block elements and
+	// <br> on separate lines and the whitespace within each line normalized.  The `visibletext`
+	// type gathers the text nodes like `text` does, but skips the contents of script, style,
+	// noscript and template elements, as does `innertext`.  The `html` and
+	// `outerhtml` types assign the rendered markup of the matching element's children, or
+	// the matching element itself, respectively.  The `exists` type assigns true whenever
+	// the selector matches an element, regardless of the element's content.  The `attrs`
+	// type fills a map with the attributes of the matching element.  When a prefix is
+	// given, such as `attrs:data-`, only the attributes beginning with the prefix are
+	// included and the prefix is removed from the map keys.  The `json` type decodes the
+	// text of the matching element, typically a script, into the field using encoding/json.
+	// A JavaScript assignment target may follow the type, such as `json:window.__STATE__`,
+	// in which case the JSON value assigned to it is decoded.  The `url` type assigns the
+	// named attribute, or the href or src attribute when no name is given, resolved against
+	// the document's base URL (see the BaseURL Option).  Values assigned to url.URL fields
+	// are always resolved
 	TypeTagName = "scrapeType"
+
+	// TimeTagName (scrapeTime) is the tag used to specify the layouts, as understood by
+	// time.Parse, for time.Time fields.  Multiple layouts are separated by a pipe (|) and
+	// are tried in order until one succeeds.  When omitted, values are parsed as RFC 3339
+	TimeTagName = "scrapeTime"
+
+	// OptionsTagName (scrapeOptions) is the tag used to specify a comma separated list of
+	// options for the field.  The `required` option causes a NoMatchError when the selector
+	// does not match any elements and `optional` exempts the field from the Strict Option.
+	// The `outermost` and `all` options set the Traversal for the field.  Fields that are
+	// not slices are assigned the first match unless the `last` or `index:n` option is
+	// given.  Negative indexes count backwards from the last match.  The `unique` option
+	// returns a MultipleMatchError when the selector matches more than one element.  The
+	// `normalize` option normalizes whitespace, just like the NormalizeSpace Option.  The
+	// `skiphidden` option makes the `visibletext` and `innertext` types also skip elements
+	// with the hidden attribute, aria-hidden="true" or an inline display:none style
+	OptionsTagName = "scrapeOptions"
+
+	// KeyTagName (scrapeKey) is the tag used to specify the CSS selector for the key of
+	// each map entry.  Map fields are filled with an entry for each element matching the
+	// scraper selector.  The key selector is then evaluated relative to that element and
+	// an empty selector refers to the element itself.  Keys are trimmed of whitespace
+	KeyTagName = "scrapeKey"
+
+	// KeyTypeTagName (scrapeKeyType) is the tag used to specify the kind of value lookup,
+	// with the same values as scrapeType, for map keys.  The default is `text`
+	KeyTypeTagName = "scrapeKeyType"
+
+	// ValueTagName (scrapeValue) is the tag used to specify the CSS selector for the value
+	// of each map entry.  Like the key selector, it is evaluated relative to the entry's
+	// element.  The scrapeType tag determines the kind of value lookup for map values
+	ValueTagName = "scrapeValue"
+
+	// RegexTagName (scrapeRegex) is the tag used to specify a regular expression, as
+	// understood by the regexp package, that extracts part of the value before it is
+	// assigned.  The capture group named `value` is used, or else the first capture group,
+	// or else the entire match.  A RegexMismatchError is returned when the expression does
+	// not match
+	RegexTagName = "scrapeRegex"
+
+	// FilterTagName (scrapeFilter) is the tag used to specify a pipeline of filters that
+	// transform the value, after any regular expression, before it is assigned.  Filters are
+	// separated by a pipe (|) and their arguments follow their name, separated by colons, as
+	// in `trim|lower|replace:,:|prefix:$`.  The built in filters are `trim` (surrounding
+	// whitespace, or the characters given), `lower`, `upper`, `collapse` (runs of whitespace),
+	// `replace:old:new`, `prefix:p` and `suffix:s` (removing p or s).  Additional filters can
+	// be registered with the Filter Option.  An UnknownFilterError is returned before anything
+	// is unmarshaled when a field names a filter that is neither built in nor registered
+	FilterTagName = "scrapeFilter"
 )
 
 type tagType int
@@ -57,46 +145,119 @@ func (tt *tagType) UnmarshalString(str string) (err error) {
 		fallthrough
 	case "text":
 		*tt = text
+	case "owntext":
+		*tt = ownText
+	case "innertext":
+		*tt = innerText
+	case "visibletext":
+		*tt = visibleText
 	case "attr":
 		*tt = attr
+	case "exists":
+		*tt = exists
+	case "html":
+		*tt = innerHTML
+	case "outerhtml":
+		*tt = outerHTML
+	case "attrs":
+		*tt = attrs
+	case "json":
+		*tt = embeddedJSON
+	case "url":
+		*tt = resolvedURL
 	default:
 		err = ErrUnknownTagType
 	}
 	return err
 }
 
+// normalizable determines if whitespace can be normalized in values
+// of the type without changing their meaning
+func (tt tagType) normalizable() bool {
+	switch tt {
+	case innerHTML, outerHTML, embeddedJSON, innerText:
+		return false
+	}
+	return true
+}
+
 const (
 	text tagType = iota
 	attr
+	innerHTML
+	outerHTML
+	ownText
+	exists
+	attrs
+	embeddedJSON
+	resolvedURL
+	innerText
+	visibleText
 )
 
 type tag struct {
-	selector cascadia.Selector
-	typ      tagType
-	detail   string
+	query        string
+	selector     selector
+	alternatives []alternative
+	typ          tagType
+	detail       string
+	layouts      []string
+	required     bool
+	optional     bool
+	traversal    Traversal
+	index        int
+	unique       bool
+	normalize    bool
+	skipHidden   bool
+	boolAttr     bool
+	regex        *regexp.Regexp
+	group        int
+	filters      []filterCall
+	key          *tag
+	value        *tag
+	conv         converter
 }
 
 func parseTag(field reflect.StructField) (t *tag, err error) {
 	t = &tag{}
 	if tag, found := field.Tag.Lookup(SelectorTagName); found {
 		err = t.parse(tag, field.Tag.Get(TypeTagName))
+		if layouts := field.Tag.Get(TimeTagName); layouts != "" {
+			t.layouts = strings.Split(layouts, "|")
+		}
+
+		if err == nil {
+			err = t.parseOptions(field.Tag.Get(OptionsTagName))
+		}
+
+		if expr, found := field.Tag.Lookup(RegexTagName); found && err == nil {
+			err = t.parseRegex(expr)
+		}
+
+		if filters := field.Tag.Get(FilterTagName); filters != "" {
+			t.filters = parseFilters(filters)
+		}
+
+		if key, found := field.Tag.Lookup(KeyTagName); found && err == nil {
+			err = t.parseEntry(key, field.Tag.Get(KeyTypeTagName), field.Tag.Get(ValueTagName))
+		}
 	} else {
 		err = errNoTag
 	}
 	return t, err
 }
 
-func (t *tag) matches(node *selection) bool {
+// matches determines if node matches the tag's selector when
+// evaluated relative to the scope element
+func (t *tag) matches(node, scope *selection) bool {
 	if t.selector == nil {
 		return true
 	}
-	return t.selector.Match(node.Node)
+	return t.selector.Match(node.Node, scope.Node)
 }
 
 func (t *tag) parse(tagStr, typeStr string) (err error) {
-	if tagStr != "" {
-		t.selector, err = cascadia.Compile(tagStr)
-	}
+	err = t.compile(tagStr)
 	if err == nil {
 		typFields := strings.Split(typeStr, ":")
 		err = t.typ.UnmarshalString(typFields[0])
@@ -107,33 +268,290 @@ func (t *tag) parse(tagStr, typeStr string) (err error) {
 	return err
 }
 
+// parseEntry parses the key and value tags of a map field.  The value tag
+// shares everything but the selector with the map field's own tag
+func (t *tag) parseEntry(keyStr, keyTypeStr, valueStr string) (err error) {
+	key := &tag{}
+	if err = key.parse(keyStr, keyTypeStr); err != nil {
+		return err
+	}
+
+	value := *t
+	if err = value.compile(valueStr); err != nil {
+		return err
+	}
+	t.key, t.value = key, &value
+	return nil
+}
+
+// parseRegex compiles the tag's regular expression and chooses the
+// capture group whose text replaces the value
+func (t *tag) parseRegex(expr string) (err error) {
+	if t.regex, err = regexp.Compile(expr); err != nil {
+		return err
+	}
+
+	if t.group = t.regex.SubexpIndex("value"); t.group < 0 {
+		t.group = 0
+		if t.regex.NumSubexp() > 0 {
+			t.group = 1
+		}
+	}
+	return nil
+}
+
+// extract returns the text of the regular expression's
+// capture group within value
+func (t *tag) extract(value string) (string, error) {
+	match := t.regex.FindStringSubmatch(value)
+	if match == nil {
+		return "", &RegexMismatchError{Value: value, Regex: t.regex.String()}
+	}
+	return match[t.group], nil
+}
+
+// compile sets the tag's query and compiles its selector along
+// with any alternatives.  An empty query matches every element
+func (t *tag) compile(query string) error {
+	t.query, t.selector, t.alternatives = query, nil, nil
+	if query == "" {
+		return nil
+	}
+
+	alts, err := compileAlternatives(query)
+	if err == nil {
+		t.selector = alts[0].selector
+		if len(alts) > 1 {
+			t.alternatives = alts
+		}
+	}
+	return err
+}
+
+func (t *tag) parseOptions(optStr string) (err error) {
+	if optStr == "" {
+		return nil
+	}
+
+	for _, opt := range strings.Split(optStr, ",") {
+		opt = strings.TrimSpace(opt)
+		if strings.HasPrefix(opt, "index:") {
+			if t.index, err = strconv.Atoi(strings.TrimPrefix(opt, "index:")); err != nil {
+				return ErrUnknownTagOption
+			}
+			continue
+		}
+
+		switch opt {
+		case "required":
+			t.required = true
+		case "optional":
+			t.optional = true
+		case "outermost":
+			t.traversal = Outermost
+		case "all":
+			t.traversal = AllMatches
+		case "first":
+			t.index = 0
+		case "last":
+			t.index = -1
+		case "unique":
+			t.unique = true
+		case "normalize":
+			t.normalize = true
+		case "skiphidden":
+			t.skipHidden = true
+		default:
+			return ErrUnknownTagOption
+		}
+	}
+	return nil
+}
+
+// pick returns the match chosen by the tag's index, or
+// nothing if the index is out of range
+func (t *tag) pick(matches []*selection) []*selection {
+	i := t.index
+	if i < 0 {
+		i += len(matches)
+	}
+
+	if i < 0 || i >= len(matches) {
+		return nil
+	}
+	return matches[i : i+1]
+}
+
 type field struct {
 	reflect.Value
-	tag *tag
+	tag  *tag
+	path string
 }
 
-func (f *field) set(value string) error {
-	switch f.Kind() {
+// multiple determines if the field receives every
+// match rather than a single one
+func (f *field) multiple() bool {
+	if f.tag.typ == embeddedJSON {
+		return false
+	}
+	return (f.Kind() == reflect.Slice || (f.Kind() == reflect.Map && f.tag.key != nil)) && !implementsUnmarshaler(f.Type())
+}
+
+// annotate records the field's path and selector in errors that locate
+// their field and wraps any other error in a FieldError.  The innermost
+// field is the most specific, so existing context is kept
+func (f *field) annotate(err error) error {
+	switch e := err.(type) {
+	case nil, UnmarshalErrors:
+	case locatedError:
+		if _, field, selector := e.location(); *field == "" {
+			*field = f.path
+			*selector = f.tag.query
+		}
+	default:
+		err = &FieldError{Err: err, Field: f.path, Selector: f.tag.query}
+	}
+	return err
+}
+
+// converter parses a string value and assigns it to v
+type converter func(v reflect.Value, value string) error
+
+// converterFor returns the converter for values of type t or
+// nil if there is no conversion from a string to t
+func converterFor(t reflect.Type) converter {
+	if conv, found := typeConverters[t]; found {
+		return conv
+	}
+
+	switch t.Kind() {
 	case reflect.String:
-		f.SetString(value)
+		return setString
+	case reflect.Bool:
+		return setBool
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		n, err := strconv.ParseInt(value, 10, 64)
-		if err != nil || f.OverflowInt(n) {
-			return &UnmarshalTypeError{Value: "number " + value, Type: f.Type()}
-		}
-		f.SetInt(n)
+		return setInt
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		n, err := strconv.ParseUint(value, 10, 64)
-		if err != nil || f.OverflowUint(n) {
-			return &UnmarshalTypeError{Value: "number " + value, Type: f.Type()}
-		}
-		f.SetUint(n)
+		return setUint
 	case reflect.Float32, reflect.Float64:
-		n, err := strconv.ParseFloat(value, f.Type().Bits())
-		if err != nil || f.OverflowFloat(n) {
-			return &UnmarshalTypeError{Value: "number " + value, Type: f.Type()}
-		}
-		f.SetFloat(n)
+		return setFloat
+	case reflect.Complex64, reflect.Complex128:
+		return setComplex
+	}
+	return nil
+}
+
+func (f *field) set(value string) error {
+	var conv converter
+	if f.tag != nil {
+		conv = f.tag.conv
+	}
+
+	if conv == nil {
+		conv = converterFor(f.Type())
+	}
+
+	if conv == nil {
+		return &UnsupportedTypeError{Type: f.Type()}
+	}
+	return conv(f.Value, value)
+}
+
+func setString(v reflect.Value, value string) error {
+	v.SetString(value)
+	return nil
+}
+
+func setBool(v reflect.Value, value string) error {
+	b, err := parseBool(value)
+	if err != nil {
+		return &UnmarshalTypeError{Value: "bool " + value, Type: v.Type()}
+	}
+	v.SetBool(b)
+	return nil
+}
+
+// parseBool extends strconv.ParseBool with the words commonly
+// used for boolean values in HTML documents and forms
+func parseBool(value string) (bool, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "yes", "y", "on", "checked", "selected":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func setInt(v reflect.Value, value string) error {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || v.OverflowInt(n) {
+		return &UnmarshalTypeError{Value: "number " + value, Type: v.Type()}
+	}
+	v.SetInt(n)
+	return nil
+}
+
+func setUint(v reflect.Value, value string) error {
+	n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil || v.OverflowUint(n) {
+		return &UnmarshalTypeError{Value: "number " + value, Type: v.Type()}
+	}
+	v.SetUint(n)
+	return nil
+}
+
+func setFloat(v reflect.Value, value string) error {
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), v.Type().Bits())
+	if err != nil || v.OverflowFloat(n) {
+		return &UnmarshalTypeError{Value: "number " + value, Type: v.Type()}
+	}
+	v.SetFloat(n)
+	return nil
+}
+
+func setComplex(v reflect.Value, value string) error {
+	n, err := strconv.ParseComplex(strings.TrimSpace(value), v.Type().Bits())
+	if err != nil || v.OverflowComplex(n) {
+		return &UnmarshalTypeError{Value: "number " + value, Type: v.Type()}
+	}
+	v.SetComplex(n)
+	return nil
+}
+
+func setDuration(v reflect.Value, value string) error {
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return &UnmarshalTypeError{Value: "duration " + value, Type: v.Type()}
+	}
+	v.SetInt(int64(d))
+	return nil
+}
+
+func setBigInt(v reflect.Value, value string) error {
+	n, ok := new(big.Int).SetString(strings.TrimSpace(value), 10)
+	if !ok {
+		return &UnmarshalTypeError{Value: "number " + value, Type: v.Type()}
+	}
+	v.Set(reflect.ValueOf(n).Elem())
+	return nil
+}
+
+func setBigFloat(v reflect.Value, value string) error {
+	n, ok := new(big.Float).SetString(strings.TrimSpace(value))
+	if !ok {
+		return &UnmarshalTypeError{Value: "number " + value, Type: v.Type()}
+	}
+	v.Set(reflect.ValueOf(n).Elem())
+	return nil
+}
+
+func setBigRat(v reflect.Value, value string) error {
+	n, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return &UnmarshalTypeError{Value: "number " + value, Type: v.Type()}
 	}
+	v.Set(reflect.ValueOf(n).Elem())
 	return nil
 }
@@ -15,8 +15,12 @@
 package scraper
 
 import (
+	"math/big"
+	"net/url"
 	"reflect"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestTagParse(t *testing.T) {
@@ -30,8 +34,21 @@ func TestTagParse(t *testing.T) {
 		{"A OK", reflect.StructField{Tag: `scraper:""`}, text, "", nil},
 		{"A OK with detail", reflect.StructField{Tag: `scraper:"" scrapeType:":foobar"`}, text, "foobar", nil},
 		{"attribute instead of text", reflect.StructField{Tag: `scraper:"" scrapeType:"attr"`}, attr, "", nil},
+		{"own text", reflect.StructField{Tag: `scraper:"" scrapeType:"owntext"`}, ownText, "", nil},
+		{"exists", reflect.StructField{Tag: `scraper:"" scrapeType:"exists"`}, exists, "", nil},
+		{"inner html", reflect.StructField{Tag: `scraper:"" scrapeType:"html"`}, innerHTML, "", nil},
+		{"outer html", reflect.StructField{Tag: `scraper:"" scrapeType:"outerhtml"`}, outerHTML, "", nil},
 		{"unknown type", reflect.StructField{Tag: `scraper:"" scrapeType:"foo"`}, text, "", ErrUnknownTagType},
 		{"no tag", reflect.StructField{}, text, "", errNoTag},
+		{"options", reflect.StructField{Tag: `scraper:"" scrapeOptions:"required, optional, all, outermost"`}, text, "", nil},
+		{"index options", reflect.StructField{Tag: `scraper:"" scrapeOptions:"first,last,index:-2,unique"`}, text, "", nil},
+		{"bad index", reflect.StructField{Tag: `scraper:"" scrapeOptions:"index:two"`}, text, "", ErrUnknownTagOption},
+		{"attrs", reflect.StructField{Tag: `scraper:"" scrapeType:"attrs:data-"`}, attrs, "data-", nil},
+		{"visible text", reflect.StructField{Tag: `scraper:"" scrapeType:"visibletext" scrapeOptions:"skiphidden,normalize"`}, visibleText, "", nil},
+		{"json", reflect.StructField{Tag: `scraper:"" scrapeType:"json:window.state"`}, embeddedJSON, "window.state", nil},
+		{"map entry", reflect.StructField{Tag: `scraper:"tr" scrapeKey:"th" scrapeKeyType:"attr:id" scrapeValue:"td"`}, text, "", nil},
+		{"map entry unknown key type", reflect.StructField{Tag: `scraper:"tr" scrapeKey:"th" scrapeKeyType:"foo"`}, text, "", ErrUnknownTagType},
+		{"unknown option", reflect.StructField{Tag: `scraper:"" scrapeOptions:"foo"`}, text, "", ErrUnknownTagOption},
 	}
 
 	for _, test := range tests {
@@ -65,7 +82,7 @@ func TestTagMatches(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got := test.tag.matches(test.input)
+			got := test.tag.matches(test.input, test.input)
 			if test.want != got {
 				t.Errorf("Wanted %v match got %v", test.want, got)
 			}
@@ -73,17 +90,61 @@ func TestTagMatches(t *testing.T) {
 	}
 }
 
+func TestTagRegex(t *testing.T) {
+	tests := []struct {
+		name    string
+		regex   string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"whole match", `\d+`, "Qty 12 left", "12", false},
+		{"first group", `\$([\d,.]+)`, "Price: $1,299.00 (incl. VAT)", "1,299.00", false},
+		{"named group", `(\w+): (?P<value>[\d.]+)`, "Price: 9.99", "9.99", false},
+		{"mismatch", `\d+`, "sold out", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tag, err := parseTag(reflect.StructField{Tag: reflect.StructTag(`scraper:"" scrapeRegex:` + strconv.Quote(test.regex))})
+			if err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+
+			got, gotErr := tag.extract(test.input)
+			if test.wantErr {
+				if _, ok := gotErr.(*RegexMismatchError); !ok {
+					t.Errorf("Wanted *RegexMismatchError got %v", gotErr)
+				}
+			} else if gotErr != nil {
+				t.Errorf("Unexpected error %v", gotErr)
+			} else if test.want != got {
+				t.Errorf("Wanted %q got %q", test.want, got)
+			}
+		})
+	}
+
+	if _, err := parseTag(reflect.StructField{Tag: `scraper:"" scrapeRegex:"("`}); err == nil {
+		t.Errorf("Wanted an error for an invalid regex")
+	}
+}
+
 func TestFieldSet(t *testing.T) {
-	isErr := func(wantErr *UnmarshalTypeError, gotErr error) bool {
+	isErr := func(wantErr error, gotErr error) bool {
 		if wantErr == nil && gotErr == nil {
 			return true
 		} else if wantErr == nil || gotErr == nil {
 			return false
 		}
 
-		if ge, ok := gotErr.(*UnmarshalTypeError); ok {
-			if *wantErr == *ge {
-				return true
+		switch we := wantErr.(type) {
+		case *UnmarshalTypeError:
+			if ge, ok := gotErr.(*UnmarshalTypeError); ok {
+				return *we == *ge
+			}
+		case *UnsupportedTypeError:
+			if ge, ok := gotErr.(*UnsupportedTypeError); ok {
+				return *we == *ge
 			}
 		}
 		return false
@@ -94,15 +155,35 @@ func TestFieldSet(t *testing.T) {
 		input    string
 		receiver reflect.Value
 		want     reflect.Value
-		wantErr  *UnmarshalTypeError
+		wantErr  error
 	}{
 		{"string", "foo", reflect.New(reflect.TypeOf("")), reflect.ValueOf("foo"), nil},
+		{"bool", "true", reflect.New(reflect.TypeOf(false)), reflect.ValueOf(true), nil},
+		{"bool yes", "Yes", reflect.New(reflect.TypeOf(false)), reflect.ValueOf(true), nil},
+		{"bool padded", " true ", reflect.New(reflect.TypeOf(false)), reflect.ValueOf(true), nil},
+		{"bool off", "off", reflect.New(reflect.TypeOf(false)), reflect.ValueOf(false), nil},
+		{"bool error", "maybe", reflect.New(reflect.TypeOf(false)), reflect.Value{}, &UnmarshalTypeError{Value: "bool " + "maybe", Type: reflect.TypeOf(false)}},
 		{"int", "1234", reflect.New(reflect.TypeOf(1)), reflect.ValueOf(1234), nil},
+		{"int padded", "42 ", reflect.New(reflect.TypeOf(1)), reflect.ValueOf(42), nil},
 		{"int error", "i1234", reflect.New(reflect.TypeOf(1)), reflect.Value{}, &UnmarshalTypeError{Value: "number " + "i1234", Type: reflect.TypeOf(1)}},
 		{"uint", "5678", reflect.New(reflect.TypeOf(uint(1))), reflect.ValueOf(uint(5678)), nil},
 		{"uint error", "i5678", reflect.New(reflect.TypeOf(uint(1))), reflect.Value{}, &UnmarshalTypeError{Value: "number " + "i5678", Type: reflect.TypeOf(uint(1))}},
 		{"float", "9.1011", reflect.New(reflect.TypeOf(float32(1))), reflect.ValueOf(float32(9.1011)), nil},
 		{"float error", "i9.1011", reflect.New(reflect.TypeOf(float32(1))), reflect.Value{}, &UnmarshalTypeError{Value: "number " + "i9.1011", Type: reflect.TypeOf(float32(1))}},
+		{"complex", "1+2i", reflect.New(reflect.TypeOf(complex64(1))), reflect.ValueOf(complex64(1 + 2i)), nil},
+		{"complex error", "1+2j", reflect.New(reflect.TypeOf(complex128(1))), reflect.Value{}, &UnmarshalTypeError{Value: "number " + "1+2j", Type: reflect.TypeOf(complex128(1))}},
+		{"duration", "1h30m", reflect.New(durationType), reflect.ValueOf(90 * time.Minute), nil},
+		{"duration error", "90", reflect.New(durationType), reflect.Value{}, &UnmarshalTypeError{Value: "duration " + "90", Type: durationType}},
+		{"url", "https://example.com/p?id=1", reflect.New(urlType), reflect.ValueOf(url.URL{Scheme: "https", Host: "example.com", Path: "/p", RawQuery: "id=1"}), nil},
+		{"url error", "%zz", reflect.New(urlType), reflect.Value{}, &UnmarshalTypeError{Value: "url " + "%zz", Type: urlType}},
+		{"big int", "123456789012345678901234567890", reflect.New(bigIntType), reflect.ValueOf(*bigInt("123456789012345678901234567890")), nil},
+		{"big int leading zero", "012", reflect.New(bigIntType), reflect.ValueOf(*big.NewInt(12)), nil},
+		{"big int prefix", "0x1f", reflect.New(bigIntType), reflect.Value{}, &UnmarshalTypeError{Value: "number " + "0x1f", Type: bigIntType}},
+		{"big int error", "12.5", reflect.New(bigIntType), reflect.Value{}, &UnmarshalTypeError{Value: "number " + "12.5", Type: bigIntType}},
+		{"big rat", "3/4", reflect.New(bigRatType), reflect.ValueOf(*big.NewRat(3, 4)), nil},
+		{"big float", "1.5", reflect.New(bigFloatType), reflect.ValueOf(*new(big.Float).SetPrec(64).SetFloat64(1.5)), nil},
+		{"big float error", "one", reflect.New(bigFloatType), reflect.Value{}, &UnmarshalTypeError{Value: "number " + "one", Type: bigFloatType}},
+		{"unsupported", "foo", reflect.New(reflect.TypeOf(map[string]string{})), reflect.Value{}, &UnsupportedTypeError{Type: reflect.TypeOf(map[string]string{})}},
 	}
 
 	for _, test := range tests {
@@ -122,3 +203,8 @@ func TestFieldSet(t *testing.T) {
 		})
 	}
 }
+
+func bigInt(str string) *big.Int {
+	n, _ := new(big.Int).SetString(str, 10)
+	return n
+}
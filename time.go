@@ -0,0 +1,95 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+
+	// defaultLayouts are used when a time.Time field does not
+	// specify any layouts in its scrapeTime tag
+	defaultLayouts = []string{time.RFC3339}
+
+	// dateTimeLayouts are the valid date and time strings for
+	// the datetime attribute of an HTML time element
+	dateTimeLayouts = []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02 15:04",
+		"2006-01-02",
+		"2006-01",
+	}
+)
+
+// Location sets the time zone used to interpret time values whose
+// layouts do not include any time zone information.  The default
+// is UTC
+func Location(loc *time.Location) Option {
+	return func(u *Unmarshaler) error {
+		u.location = loc
+		return nil
+	}
+}
+
+// setTime parses the value of the selected node into the time.Time field f.
+// When the node is an HTML time element with a datetime attribute, then the
+// attribute is preferred over the text content
+func (u *Unmarshaler) setTime(f *field, n *selection) error {
+	layouts := f.tag.layouts
+	if len(layouts) == 0 {
+		layouts = defaultLayouts
+	}
+
+	value, err := u.value(f, n)
+	if f.tag.typ == text && n.DataAtom == atom.Time {
+		if datetime, found := n.lookupAttr("datetime"); found {
+			value, err = datetime, nil
+			layouts = append(append([]string{}, layouts...), dateTimeLayouts...)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	return u.parseTime(f, value, layouts)
+}
+
+// parseTime parses value into the time.Time field f using the
+// first of the layouts that succeeds
+func (u *Unmarshaler) parseTime(f *field, value string, layouts []string) error {
+	loc := u.location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			f.Set(reflect.ValueOf(t))
+			return nil
+		}
+	}
+	return &UnmarshalTypeError{Value: "time " + value, Type: f.Type()}
+}
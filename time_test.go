@@ -0,0 +1,77 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+type timeTest struct {
+	Published time.Time    `scraper:".published" scrapeTime:"January 2, 2006|2006-01-02"`
+	Updated   *time.Time   `scraper:"time"`
+	Default   time.Time    `scraper:".default"`
+	All       []*time.Time `scraper:".all" scrapeTime:"2006-01-02"`
+	Dates     []time.Time  `scraper:"li" scrapeTime:"Jan 2, 2006"`
+}
+
+func TestSetTime(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	date := func(year int, month time.Month, day int, loc *time.Location) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		options []Option
+		check   func(*timeTest) bool
+		wantErr bool
+	}{
+		{"first layout", `<p class="published"> May 1, 2019 </p>`, nil, func(v *timeTest) bool { return v.Published.Equal(date(2019, time.May, 1, time.UTC)) }, false},
+		{"fallback layout", `<p class="published">2019-05-01</p>`, nil, func(v *timeTest) bool { return v.Published.Equal(date(2019, time.May, 1, time.UTC)) }, false},
+		{"location", `<p class="published">2019-05-01</p>`, []Option{Location(est)}, func(v *timeTest) bool { return v.Published.Equal(date(2019, time.May, 1, est)) }, false},
+		{"datetime attribute", `<time datetime="2019-05-01">Yesterday</time>`, nil, func(v *timeTest) bool { return v.Updated.Equal(date(2019, time.May, 1, time.UTC)) }, false},
+		{"time text", `<time>2019-05-01T10:00:00Z</time>`, nil, func(v *timeTest) bool { return v.Updated.Equal(time.Date(2019, time.May, 1, 10, 0, 0, 0, time.UTC)) }, false},
+		{"default layout", `<p class="default">2019-05-01T10:00:00-05:00</p>`, nil, func(v *timeTest) bool { return v.Default.Equal(time.Date(2019, time.May, 1, 10, 0, 0, 0, est)) }, false},
+		{"slice", `<p class="all">2019-05-01</p><p class="all">2019-05-02</p>`, nil, func(v *timeTest) bool { return len(v.All) == 2 && v.All[1].Equal(date(2019, time.May, 2, time.UTC)) }, false},
+		{"value slice", `<li>Mar 3, 2020</li><li>Mar 4, 2020</li>`, nil, func(v *timeTest) bool { return len(v.Dates) == 2 && v.Dates[1].Equal(date(2020, 3, 4, time.UTC)) }, false},
+		{"error", `<p class="published">Tomorrow</p>`, nil, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatalf("Failed to parse html: %v", err)
+			}
+
+			got := &timeTest{}
+			gotErr := NewUnmarshaler(root, test.options...).Unmarshal(got)
+			if test.wantErr {
+				if _, ok := gotErr.(*UnmarshalTypeError); !ok {
+					t.Errorf("Wanted *UnmarshalTypeError got %v", gotErr)
+				}
+			} else if gotErr != nil {
+				t.Errorf("Unexpected error: %v", gotErr)
+			} else if !test.check(got) {
+				t.Errorf("Unexpected result %+v", got)
+			}
+		})
+	}
+}
@@ -18,8 +18,12 @@ import (
 	"bytes"
 	"encoding"
 	"errors"
+	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/net/html"
 )
@@ -41,6 +45,76 @@ func TrimSpace() Option {
 	}
 }
 
+// NormalizeSpace tells the unmarshaler to normalize whitespace in values the
+// way a browser does: runs of whitespace, including non-breaking spaces, are
+// collapsed into a single space and surrounding whitespace is removed.  Markup,
+// JSON and innertext values, which have their own whitespace rules, are left
+// unchanged.  The `normalize` option in a scrapeOptions tag does the same for a
+// single field
+func NormalizeSpace() Option {
+	return func(u *Unmarshaler) error {
+		u.normalizeSpace = true
+		return nil
+	}
+}
+
+// ContinueOnError tells the unmarshaler to keep going when a field cannot
+// be unmarshaled.  Every field that can be set will be set and all of the
+// errors that were encountered are returned together as UnmarshalErrors
+func ContinueOnError() Option {
+	return func(u *Unmarshaler) error {
+		u.continueOnError = true
+		return nil
+	}
+}
+
+// Strict makes every tagged field required, as if it had the `required` option,
+// unless the field has the `optional` option.  A NoMatchError is returned for
+// each required field whose selector does not match any elements
+func Strict() Option {
+	return func(u *Unmarshaler) error {
+		u.strict = true
+		return nil
+	}
+}
+
+// Traversal determines which of the elements matching a selector are unmarshaled
+type Traversal int
+
+const (
+	// Outermost only unmarshals matching elements that are not descendants of
+	// another matching element.  This is the default
+	Outermost Traversal = iota + 1
+
+	// AllMatches unmarshals every matching element, in document order, similar
+	// to querySelectorAll
+	AllMatches
+)
+
+// Traverse sets the Traversal used for fields that do not specify
+// one with the `outermost` or `all` options in their scrapeOptions tag
+func Traverse(traversal Traversal) Option {
+	return func(u *Unmarshaler) error {
+		u.defaultTraversal = traversal
+		return nil
+	}
+}
+
+// AlternativeFunc receives the path of a field whose selector lists alternatives,
+// such as ".price-new || #price span", along with the index and text of the
+// alternative that was used
+type AlternativeFunc func(field string, index int, selector string)
+
+// ReportAlternatives calls fn each time a field with alternative selectors is
+// unmarshaled and one of its alternatives matches.  This is useful for finding
+// out which layout of a site a document was scraped from
+func ReportAlternatives(fn AlternativeFunc) Option {
+	return func(u *Unmarshaler) error {
+		u.onAlternative = fn
+		return nil
+	}
+}
+
 // BinaryUnmarshaler is the interface implemented by an object that can unmarshal
 // the byte string (either text content or attribute) from an element matched
 // by a scraper seleector
@@ -71,9 +145,19 @@ func Unmarshal(text []byte, v interface{}) error {
 // into a receiver.  The unmarshaler looks for struct field tags
 // matching `scraper` and `scrapeType`
 type Unmarshaler struct {
-	root      *html.Node
-	trimSpace bool
-	err       error
+	root             *html.Node
+	trimSpace        bool
+	normalizeSpace   bool
+	continueOnError  bool
+	strict           bool
+	defaultTraversal Traversal
+	location         *time.Location
+	baseURL          *url.URL
+	base             *url.URL
+	baseOnce         sync.Once
+	onAlternative    AlternativeFunc
+	filters          map[string]FilterFunc
+	err              error
 }
 
 // NewUnmarshaler creates a scraper Unmarshaler with its root set to the
@@ -110,12 +194,35 @@ func (u *Unmarshaler) Unmarshal(v interface{}) (err error) {
 		return &InvalidUnmarshalError{rv.Type(), reflect.Struct}
 	}
 
-	return u.unmarshalStruct(&field{Value: rv, tag: &tag{typ: text}}, &selection{u.root})
+	if err = u.checkFilters(rv.Type(), "", make(map[reflect.Type]bool)); err == nil {
+		err = u.unmarshalStruct(&field{Value: rv, tag: &tag{typ: text}}, &selection{u.root})
+	}
+	setStruct(err, rv.Type().Name())
+	return err
+}
+
+// setStruct records the name of the root struct type in
+// any errors that have not yet recorded one
+func setStruct(err error, name string) {
+	switch e := err.(type) {
+	case locatedError:
+		if structName, _, _ := e.location(); *structName == "" {
+			*structName = name
+		}
+	case UnmarshalErrors:
+		for _, err := range e {
+			setStruct(err, name)
+		}
+	}
 }
 
 func (u *Unmarshaler) tryUnmarshaler(f *field, n *selection) error {
 	value := f.Value
-	if value.Kind() != reflect.Slice && value.Kind() != reflect.Ptr {
+	if value.Kind() == reflect.Ptr && (value.IsNil() || converted(value.Type().Elem())) {
+		// unmarshalField will allocate or follow the pointer and try again
+		return errNoUnmarshaler
+	} else if value.Kind() != reflect.Ptr {
+		// slice types such as net.IP implement their methods on a pointer
 		if value.CanAddr() {
 			value = value.Addr()
 		} else {
@@ -127,9 +234,15 @@ func (u *Unmarshaler) tryUnmarshaler(f *field, n *selection) error {
 	if value.Type().NumMethod() > 0 && value.CanInterface() {
 		switch i := value.Interface().(type) {
 		case TextUnmarshaler:
-			err = i.UnmarshalText([]byte(u.value(f, n)))
+			var text string
+			if text, err = u.value(f, n); err == nil {
+				err = i.UnmarshalText([]byte(text))
+			}
 		case BinaryUnmarshaler:
-			err = i.UnmarshalBinary([]byte(u.value(f, n)))
+			var text string
+			if text, err = u.value(f, n); err == nil {
+				err = i.UnmarshalBinary([]byte(text))
+			}
 		case HTMLUnmarshaler:
 			err = i.UnmarshalHTML(n.Node)
 		}
@@ -137,63 +250,233 @@ func (u *Unmarshaler) tryUnmarshaler(f *field, n *selection) error {
 	return err
 }
 
+// converted determines if values of type t are set by setTime or one of the
+// typeConverters, which take precedence over any unmarshaler methods
+func converted(t reflect.Type) bool {
+	_, found := typeConverters[t]
+	return found || t == timeType
+}
+
 // unmarshalStruct a struct
 func (u *Unmarshaler) unmarshalStruct(f *field, n *selection) (err error) {
 	if err = u.tryUnmarshaler(f, n); err != errNoUnmarshaler {
 		return err
 	}
-	err = nil
 
-	rt := f.Value.Type()
-	for i := 0; err == nil && i < rt.NumField(); i++ {
-		ft := rt.Field(i)
-		var t *tag
-		if t, err = parseTag(ft); err == nil {
-			err = u.walk(&field{f.Value.Field(i), t}, n)
-		} else if err == errNoTag {
-			err = nil
+	plan, err := cachedPlan(f.Value.Type())
+	if err != nil {
+		return err
+	}
+
+	var errs UnmarshalErrors
+	for _, fp := range plan.fields {
+		child := &field{Value: f.Value.Field(fp.index), tag: fp.tag, path: joinPath(f.path, fp.name)}
+		var matched int
+		if matched, err = u.walk(child, n); err == nil && matched == 0 && u.required(child.tag) {
+			err = &NoMatchError{Field: child.path, Selector: child.tag.query}
+		}
+
+		if err != nil && !u.continueOnError {
+			return err
 		}
+		errs = errs.append(err)
 	}
-	return
+	return errs.err()
+}
+
+// required determines if a field with the tag t must match an element
+func (u *Unmarshaler) required(t *tag) bool {
+	return t.required || (u.strict && !t.optional)
+}
+
+// walk unmarshals the elements, within the subtree rooted at scope, that
+// match f's selector and returns the number of elements unmarshaled.  Slice
+// fields receive every match while other fields only receive the match
+// chosen by the tag's index (the first match by default)
+func (u *Unmarshaler) walk(f *field, scope *selection) (matched int, err error) {
+	t, matches := u.search(f.tag, scope, f.multiple(), f.path)
+	if t != f.tag {
+		f = &field{Value: f.Value, tag: t, path: f.path}
+	}
+
+	if f.tag.unique && len(matches) > 1 {
+		return len(matches), &MultipleMatchError{Field: f.path, Selector: f.tag.query, Count: len(matches)}
+	}
+
+	if !f.multiple() {
+		matches = f.tag.pick(matches)
+	}
+
+	var errs UnmarshalErrors
+	for _, n := range matches {
+		if err = u.unmarshalField(f, n); err != nil && !u.continueOnError {
+			return len(matches), err
+		}
+		errs = errs.append(err)
+	}
+	return len(matches), errs.err()
 }
 
-func (u *Unmarshaler) walk(f *field, n *selection) (err error) {
-	if n.Type == html.ElementNode {
-		if f.tag.matches(n) {
-			// short circuit
-			return u.unmarshalField(f, n)
+// search returns the elements, within the subtree rooted at scope, that
+// match the tag's selector.  When the tag lists alternative selectors, they
+// are tried in order and the first one that yields a non-empty value is used.
+// If none do, the first alternative matching anything is used.  The returned
+// tag has the selector that was used
+func (u *Unmarshaler) search(t *tag, scope *selection, multiple bool, path string) (*tag, []*selection) {
+	if t.alternatives == nil {
+		return t, u.find(t, scope)
+	}
+
+	var fallback *tag
+	var fallbackMatches []*selection
+	fallbackIndex := -1
+	for i, alt := range t.alternatives {
+		at := *t
+		at.query, at.selector, at.alternatives = alt.query, alt.selector, nil
+		matches := u.find(&at, scope)
+		if u.yields(&at, matches, multiple) {
+			u.reportAlternative(path, i, alt.query)
+			return &at, matches
+		}
+
+		if fallback == nil && len(matches) > 0 {
+			fallback, fallbackMatches, fallbackIndex = &at, matches, i
 		}
 	}
 
-	for c := n.FirstChild; err == nil && c != nil; c = c.NextSibling {
-		err = u.walk(f, &selection{c})
+	if fallback == nil {
+		return t, nil
 	}
-	return
+	u.reportAlternative(path, fallbackIndex, fallback.query)
+	return fallback, fallbackMatches
 }
 
-func (u *Unmarshaler) value(f *field, n *selection) string {
-	value := n.value(f.tag)
+// yields determines if any of the matches that a field receives has a
+// non-empty value
+func (u *Unmarshaler) yields(t *tag, matches []*selection, multiple bool) bool {
+	if !multiple {
+		matches = t.pick(matches)
+	}
+
+	for _, n := range matches {
+		if t.typ == attrs {
+			if len(n.Attr) > 0 {
+				return true
+			}
+		} else if value, err := u.process(t, n.value(t)); err == nil && value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *Unmarshaler) reportAlternative(path string, index int, query string) {
+	if u.onAlternative != nil {
+		u.onAlternative(path, index, query)
+	}
+}
+
+// find returns the elements, within the subtree rooted at scope, that
+// match the tag's selector in document order.  Unless the traversal is
+// AllMatches, the descendants of a matching element are not searched.
+// Selectors that begin with a sibling combinator, such as "+ dd", also
+// search the siblings following the scope
+func (u *Unmarshaler) find(t *tag, scope *selection) (matches []*selection) {
+	all := u.traversal(t) == AllMatches
+
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && t.matches(&selection{n}, scope) {
+			matches = append(matches, &selection{n})
+			if !all {
+				return
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+
+	f(scope.Node)
+	if t.selector.siblings() {
+		for sibling := scope.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+			f(sibling)
+		}
+	}
+	return matches
+}
+
+// traversal returns the traversal for a field with the tag t
+func (u *Unmarshaler) traversal(t *tag) Traversal {
+	if t.traversal != 0 {
+		return t.traversal
+	}
+	return u.defaultTraversal
+}
+
+func (u *Unmarshaler) value(f *field, n *selection) (string, error) {
+	value, err := u.process(f.tag, n.value(f.tag))
+	if err == nil && resolvesURL(f) {
+		value = u.resolve(value)
+	}
+	return value, err
+}
+
+// process applies the transformations configured for the
+// Unmarshaler and the tag t to a raw value
+func (u *Unmarshaler) process(t *tag, value string) (string, error) {
 	if u.trimSpace {
 		value = strings.TrimSpace(value)
 	}
-	return value
+
+	if (u.normalizeSpace || t.normalize) && t.typ.normalizable() {
+		value = normalizeSpace(value)
+	}
+
+	var err error
+	if t.regex != nil {
+		if value, err = t.extract(value); err != nil {
+			return "", err
+		}
+	}
+
+	if t.filters != nil {
+		return u.filter(t.filters, value)
+	}
+	return value, nil
 }
 
 func (u *Unmarshaler) unmarshalField(f *field, n *selection) (err error) {
-	if err = u.tryUnmarshaler(f, n); err != errNoUnmarshaler {
-		return err
+	if f.tag.typ == embeddedJSON {
+		err = u.unmarshalJSON(f, n)
+	} else if f.Type() == timeType {
+		err = u.setTime(f, n)
+	} else if conv, found := typeConverters[f.Type()]; found {
+		var value string
+		if value, err = u.value(f, n); err == nil {
+			err = conv(f.Value, value)
+		}
+	} else if err = u.tryUnmarshaler(f, n); err == errNoUnmarshaler {
+		err = u.unmarshalKind(f, n)
 	}
+	return f.annotate(err)
+}
 
+func (u *Unmarshaler) unmarshalKind(f *field, n *selection) (err error) {
 	switch f.Kind() {
 	case reflect.Slice:
-		newField := &field{reflect.New(f.Type().Elem()), f.tag}
-		err = u.unmarshalField(newField, n)
-		if err == nil {
-			if f.Type().Elem().Kind() == reflect.Ptr {
-				f.Set(reflect.Append(f.Value, newField.Value.Addr()))
-			} else {
-				f.Set(reflect.Append(f.Value, reflect.Indirect(newField.Value)))
-			}
+		// unmarshalField replaces elem.Value when following pointers
+		value := reflect.New(f.Type().Elem()).Elem()
+		elem := &field{Value: value, tag: f.tag, path: f.path + "[" + strconv.Itoa(f.Len()) + "]"}
+		if err = u.unmarshalField(elem, n); err == nil {
+			f.Set(reflect.Append(f.Value, value))
+		}
+	case reflect.Map:
+		if f.tag.typ == attrs {
+			err = u.unmarshalAttrs(f, n)
+		} else {
+			err = u.unmarshalMap(f, n)
 		}
 	case reflect.Struct:
 		err = u.unmarshalStruct(f, n)
@@ -204,8 +487,80 @@ func (u *Unmarshaler) unmarshalField(f *field, n *selection) (err error) {
 		f.Value = reflect.Indirect(f.Value)
 		err = u.unmarshalField(f, n)
 	default:
-		err = f.set(u.value(f, n))
+		var value string
+		if value, err = u.value(f, n); err == nil {
+			err = f.set(value)
+		}
 	}
 
 	return
 }
+
+// joinPath appends the struct field name to a dotted field path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// unmarshalMap adds an entry to the map field f using the key and value
+// found within the entry element n.  Entries that have no matching key
+// or value element are skipped
+func (u *Unmarshaler) unmarshalMap(f *field, n *selection) (err error) {
+	if f.tag.key == nil {
+		return &UnsupportedTypeError{Type: f.Type()}
+	}
+
+	keyTag, keyNodes := u.search(f.tag.key, n, false, f.path)
+	valueTag, valueNodes := u.search(f.tag.value, n, false, f.path)
+	keyNodes, valueNodes = keyTag.pick(keyNodes), valueTag.pick(valueNodes)
+	if len(keyNodes) == 0 || len(valueNodes) == 0 {
+		return nil
+	}
+
+	keyStr := strings.TrimSpace(keyNodes[0].value(keyTag))
+	key := &field{Value: reflect.New(f.Type().Key()).Elem(), tag: keyTag, path: f.path}
+	if err = key.set(keyStr); err != nil {
+		return err
+	}
+
+	// unmarshalField replaces value.Value when following pointers
+	elem := reflect.New(f.Type().Elem()).Elem()
+	value := &field{Value: elem, tag: valueTag, path: f.path + "[" + strconv.Quote(keyStr) + "]"}
+	if err = u.unmarshalField(value, valueNodes[0]); err == nil {
+		if f.IsNil() {
+			f.Set(reflect.MakeMap(f.Type()))
+		}
+		f.SetMapIndex(key.Value, elem)
+	}
+	return err
+}
+
+// unmarshalAttrs adds the attributes of n, whose names begin with the tag's
+// prefix, to the map field f.  The prefix is removed from the map keys
+func (u *Unmarshaler) unmarshalAttrs(f *field, n *selection) error {
+	if f.IsNil() {
+		f.Set(reflect.MakeMap(f.Type()))
+	}
+
+	for _, a := range n.Attr {
+		if !strings.HasPrefix(a.Key, f.tag.detail) {
+			continue
+		}
+
+		key := strings.TrimPrefix(a.Key, f.tag.detail)
+		elem := reflect.New(f.Type().Elem()).Elem()
+		value := &field{Value: elem, tag: f.tag, path: f.path + "[" + strconv.Quote(key) + "]"}
+		str, err := u.process(f.tag, a.Val)
+		if err == nil {
+			err = value.set(str)
+		}
+
+		if err != nil {
+			return value.annotate(err)
+		}
+		f.SetMapIndex(reflect.ValueOf(key).Convert(f.Type().Key()), elem)
+	}
+	return nil
+}
@@ -15,8 +15,15 @@
 package scraper
 
 import (
+	"errors"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/net/html"
 )
@@ -70,7 +77,7 @@ func TestTryUnmarshal(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			u := &Unmarshaler{}
-			f := &field{reflect.ValueOf(test.input), &tag{}}
+			f := &field{Value: reflect.ValueOf(test.input), tag: &tag{}}
 			gotErr := u.tryUnmarshaler(f, &selection{})
 			if test.wantErr == gotErr {
 				if gotErr == nil {
@@ -85,3 +92,546 @@ func TestTryUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+type testErrorContext struct {
+	Listing struct {
+		Prices []struct {
+			Amount int `scraper:".amount"`
+		} `scraper:".price"`
+	} `scraper:".listing"`
+}
+
+func TestUnmarshalTypeErrorContext(t *testing.T) {
+	input := `<div class="listing">
+		<p class="price"><span class="amount">1</span></p>
+		<p class="price"><span class="amount">abc</span></p>
+	</div>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	want := &UnmarshalTypeError{Value: "number abc", Type: reflect.TypeOf(0), Struct: "testErrorContext", Field: "Listing.Prices[1].Amount", Selector: ".amount"}
+	gotErr := NewUnmarshaler(root).Unmarshal(&testErrorContext{})
+	if got, ok := gotErr.(*UnmarshalTypeError); ok {
+		if *want != *got {
+			t.Errorf("Wanted error %+v got %+v", want, got)
+		}
+	} else {
+		t.Errorf("Wanted *UnmarshalTypeError got %v", gotErr)
+	}
+}
+
+type testContinueOnError struct {
+	Counts []int  `scraper:".count"`
+	Name   string `scraper:".name"`
+	Price  int    `scraper:".price"`
+}
+
+func TestContinueOnError(t *testing.T) {
+	input := `<p class="count">1</p><p class="count">two</p><p class="count">3</p><p class="price">free</p><p class="name">Widget</p>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	got := &testContinueOnError{}
+	gotErr := NewUnmarshaler(root, ContinueOnError()).Unmarshal(got)
+
+	var errs UnmarshalErrors
+	if !errors.As(gotErr, &errs) {
+		t.Fatalf("Wanted UnmarshalErrors got %v", gotErr)
+	}
+
+	wantFields := []string{"Counts[1]", "Price"}
+	if len(errs) != len(wantFields) {
+		t.Fatalf("Wanted %d errors got %d: %v", len(wantFields), len(errs), errs)
+	}
+
+	for i, err := range errs {
+		var ute *UnmarshalTypeError
+		if !errors.As(err, &ute) {
+			t.Errorf("Wanted *UnmarshalTypeError got %v", err)
+		} else if ute.Field != wantFields[i] || ute.Struct != "testContinueOnError" {
+			t.Errorf("Wanted field testContinueOnError.%s got %s.%s", wantFields[i], ute.Struct, ute.Field)
+		}
+	}
+
+	want := &testContinueOnError{Counts: []int{1, 3}, Name: "Widget"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted %+v got %+v", want, got)
+	}
+}
+
+var errBadValue = errors.New("bad value")
+
+type testFailingUnmarshaler struct{}
+
+func (t *testFailingUnmarshaler) UnmarshalText([]byte) error {
+	return errBadValue
+}
+
+type testFieldErrors struct {
+	Value  testFailingUnmarshaler `scraper:".value"`
+	State  map[string]int         `scraper:"script.state" scrapeType:"json:window.__STATE__"`
+	Config map[string]int         `scraper:"script.config" scrapeType:"json"`
+}
+
+func TestFieldErrors(t *testing.T) {
+	input := `<p class="value">x</p>
+		<script class="state">var state = {};</script>
+		<script class="config">{"a": </script>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	gotErr := NewUnmarshaler(root, ContinueOnError()).Unmarshal(&testFieldErrors{})
+	var errs UnmarshalErrors
+	if !errors.As(gotErr, &errs) {
+		t.Fatalf("Wanted UnmarshalErrors got %v", gotErr)
+	}
+
+	wantFields := []string{"Value", "State", "Config"}
+	if len(errs) != len(wantFields) {
+		t.Fatalf("Wanted %d errors got %d: %v", len(wantFields), len(errs), errs)
+	}
+
+	for i, err := range errs {
+		var fe *FieldError
+		if !errors.As(err, &fe) {
+			t.Errorf("Wanted *FieldError got %v", err)
+		} else if fe.Field != wantFields[i] || fe.Struct != "testFieldErrors" {
+			t.Errorf("Wanted field testFieldErrors.%s got %s.%s", wantFields[i], fe.Struct, fe.Field)
+		}
+	}
+
+	if !errors.Is(errs[0], errBadValue) {
+		t.Errorf("Wanted error %v got %v", errBadValue, errs[0])
+	}
+
+	if !errors.Is(errs[1], ErrNoAssignment) {
+		t.Errorf("Wanted error %v got %v", ErrNoAssignment, errs[1])
+	}
+}
+
+type testRequired struct {
+	Name  string `scraper:".name" scrapeOptions:"required"`
+	Price string `scraper:".price"`
+	Notes string `scraper:".notes" scrapeOptions:"optional"`
+}
+
+func TestRequired(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		options []Option
+		want    []NoMatchError
+	}{
+		{"all present", `<p class="name">a</p><p class="price">1</p><p class="notes">n</p>`, []Option{Strict()}, nil},
+		{"required missing", `<p class="price">1</p>`, nil, []NoMatchError{{"testRequired", "Name", ".name"}}},
+		{"strict", `<p class="name">a</p>`, []Option{Strict()}, []NoMatchError{{"testRequired", "Price", ".price"}}},
+		{"strict continue", ``, []Option{Strict(), ContinueOnError()}, []NoMatchError{{"testRequired", "Name", ".name"}, {"testRequired", "Price", ".price"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := html.Parse(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatalf("Failed to parse html: %v", err)
+			}
+
+			gotErr := NewUnmarshaler(root, test.options...).Unmarshal(&testRequired{})
+			var got []NoMatchError
+			if errs, ok := gotErr.(UnmarshalErrors); ok {
+				for _, err := range errs {
+					if nme, ok := err.(*NoMatchError); ok {
+						got = append(got, *nme)
+					}
+				}
+			} else if nme, ok := gotErr.(*NoMatchError); ok {
+				got = append(got, *nme)
+			} else if gotErr != nil {
+				t.Fatalf("Unexpected error %v", gotErr)
+			}
+
+			if !reflect.DeepEqual(test.want, got) {
+				t.Errorf("Wanted errors %v got %v", test.want, got)
+			}
+		})
+	}
+}
+
+type testTraversal struct {
+	Default   []string `scraper:"li"`
+	All       []string `scraper:"li" scrapeOptions:"all"`
+	Outermost []string `scraper:"li" scrapeOptions:"outermost"`
+}
+
+func TestTraversal(t *testing.T) {
+	input := `<ul><li>1<ul><li>2</li></ul></li><li>3</li></ul>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		options []Option
+		want    *testTraversal
+	}{
+		{"default", nil, &testTraversal{[]string{"12", "3"}, []string{"12", "2", "3"}, []string{"12", "3"}}},
+		{"all", []Option{Traverse(AllMatches)}, &testTraversal{[]string{"12", "2", "3"}, []string{"12", "2", "3"}, []string{"12", "3"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := &testTraversal{}
+			if err := NewUnmarshaler(root, test.options...).Unmarshal(got); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+
+			if !reflect.DeepEqual(test.want, got) {
+				t.Errorf("Wanted %+v got %+v", test.want, got)
+			}
+		})
+	}
+}
+
+type testIndex struct {
+	Default  string  `scraper:"li"`
+	Last     string  `scraper:"li" scrapeOptions:"last"`
+	Second   string  `scraper:"li" scrapeOptions:"index:1"`
+	Previous string  `scraper:"li" scrapeOptions:"index:-2"`
+	Missing  *string `scraper:"li" scrapeOptions:"index:5"`
+}
+
+type testUnique struct {
+	Item string `scraper:"li" scrapeOptions:"unique"`
+}
+
+func TestIndex(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<ul><li>1</li><li>2</li><li>3</li></ul>`))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	got := &testIndex{}
+	if err := NewUnmarshaler(root).Unmarshal(got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := &testIndex{Default: "1", Last: "3", Second: "2", Previous: "2"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted %+v got %+v", want, got)
+	}
+
+	wantErr := &MultipleMatchError{Struct: "testUnique", Field: "Item", Selector: "li", Count: 3}
+	gotErr := NewUnmarshaler(root).Unmarshal(&testUnique{})
+	if ge, ok := gotErr.(*MultipleMatchError); !ok || *wantErr != *ge {
+		t.Errorf("Wanted error %v got %v", wantErr, gotErr)
+	}
+}
+
+type testMap struct {
+	Specs      map[string]string `scraper:"tr" scrapeKey:"th" scrapeValue:"td"`
+	Terms      map[string]string `scraper:"dt" scrapeKey:"" scrapeValue:"+ dd"`
+	Counts     map[string]int    `scraper:"li" scrapeKey:"" scrapeKeyType:"attr:data-key" scrapeValue:""`
+	Dimensions map[string]*struct {
+		Value string `scraper:".value"`
+		Unit  string `scraper:".unit"`
+	} `scraper:"tr" scrapeKey:"th" scrapeValue:"td"`
+}
+
+func TestUnmarshalMap(t *testing.T) {
+	input := `<table>
+		<tr><th> Weight </th><td><span class="value">2</span><span class="unit">kg</span></td></tr>
+		<tr><th>Color</th><td>red</td></tr>
+		<tr><td>no key</td></tr>
+	</table>
+	<dl><dt>Term</dt><dd>Definition</dd><dt>Other</dt><dd>Thing</dd></dl>
+	<ul><li data-key="a">1</li><li data-key="b">2</li></ul>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	got := &testMap{}
+	if err := NewUnmarshaler(root).Unmarshal(got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if want := map[string]string{"Weight": "2kg", "Color": "red"}; !reflect.DeepEqual(want, got.Specs) {
+		t.Errorf("Wanted specs %v got %v", want, got.Specs)
+	}
+
+	if want := map[string]string{"Term": "Definition", "Other": "Thing"}; !reflect.DeepEqual(want, got.Terms) {
+		t.Errorf("Wanted terms %v got %v", want, got.Terms)
+	}
+
+	if want := map[string]int{"a": 1, "b": 2}; !reflect.DeepEqual(want, got.Counts) {
+		t.Errorf("Wanted counts %v got %v", want, got.Counts)
+	}
+
+	if d := got.Dimensions["Weight"]; d == nil || d.Value != "2" || d.Unit != "kg" {
+		t.Errorf("Wanted weight dimension {2 kg} got %+v", d)
+	}
+}
+
+func TestUnmarshalMapError(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<ul><li data-key="a">one</li></ul>`))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	v := &struct {
+		Counts map[string]int `scraper:"li" scrapeKey:"" scrapeKeyType:"attr:data-key" scrapeValue:""`
+	}{}
+	gotErr := NewUnmarshaler(root).Unmarshal(v)
+	if ute, ok := gotErr.(*UnmarshalTypeError); !ok || ute.Field != `Counts["a"]` {
+		t.Errorf("Wanted *UnmarshalTypeError for Counts[\"a\"] got %v", gotErr)
+	}
+}
+
+type testAttrs struct {
+	Data    map[string]string `scraper:"div" scrapeType:"attrs:data-"`
+	All     map[string]string `scraper:"div" scrapeType:"attrs"`
+	Numbers map[string]int    `scraper:"span" scrapeType:"attrs:data-"`
+}
+
+func TestUnmarshalAttrs(t *testing.T) {
+	input := `<div id="product" data-sku="123" data-price="9.99">Product</div><span data-count="4"></span>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	got := &testAttrs{}
+	if err := NewUnmarshaler(root).Unmarshal(got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := &testAttrs{
+		Data:    map[string]string{"sku": "123", "price": "9.99"},
+		All:     map[string]string{"id": "product", "data-sku": "123", "data-price": "9.99"},
+		Numbers: map[string]int{"count": 4},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted %+v got %+v", want, got)
+	}
+}
+
+type testStandardTypes struct {
+	URL       *url.URL        `scraper:"a" scrapeType:"attr:href"`
+	URLs      []url.URL       `scraper:"a" scrapeType:"attr:href"`
+	IP        net.IP          `scraper:".ip"`
+	IPs       []net.IP        `scraper:".ip"`
+	Addr      netip.Addr      `scraper:".ip"`
+	Timeout   time.Duration   `scraper:".timeout"`
+	Timeouts  []time.Duration `scraper:".timeout"`
+	Total     *big.Int        `scraper:".total"`
+	Ratio     big.Rat         `scraper:".ratio"`
+	Ratios    []big.Rat       `scraper:".ratio"`
+	Impedance complex128      `scraper:".impedance"`
+}
+
+func TestUnmarshalStandardTypes(t *testing.T) {
+	input := `<a href="https://example.com/p/1">Product</a>
+		<span class="ip">192.0.2.1</span><span class="ip">2001:db8::1</span>
+		<span class="timeout">1m30s</span><span class="timeout">250ms</span>
+		<span class="total">98765432109876543210</span>
+		<span class="ratio">2/3</span>
+		<span class="impedance">3-4i</span>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	got := &testStandardTypes{URL: &url.URL{}}
+	if err := NewUnmarshaler(root).Unmarshal(got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	total, _ := new(big.Int).SetString("98765432109876543210", 10)
+	want := &testStandardTypes{
+		URL:       &url.URL{Scheme: "https", Host: "example.com", Path: "/p/1"},
+		URLs:      []url.URL{{Scheme: "https", Host: "example.com", Path: "/p/1"}},
+		IP:        net.ParseIP("192.0.2.1"),
+		IPs:       []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")},
+		Addr:      netip.MustParseAddr("192.0.2.1"),
+		Timeout:   90 * time.Second,
+		Timeouts:  []time.Duration{90 * time.Second, 250 * time.Millisecond},
+		Total:     total,
+		Ratio:     *big.NewRat(2, 3),
+		Ratios:    []big.Rat{*big.NewRat(2, 3)},
+		Impedance: 3 - 4i,
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted %+v got %+v", want, got)
+	}
+}
+
+type testAlternatives struct {
+	Price  string            `scraper:".price-new || #price span || .price"`
+	Name   string            `scraper:".title || h1"`
+	Tags   []string          `scraper:".tags li || .tag"`
+	Specs  map[string]string `scraper:"tr" scrapeKey:"th || td.key" scrapeValue:"td.value || td:last-child"`
+	Rating string            `scraper:".stars || .rating" scrapeOptions:"required"`
+}
+
+func TestAlternatives(t *testing.T) {
+	input := `<h1>Widget</h1><span class="title"></span>
+		<div id="price"><span>9.99</span></div><span class="price">8.99</span>
+		<span class="tag">a</span><span class="tag">b</span>
+		<table><tr><td class="key">Color</td><td>Red</td></tr></table>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	reported := make(map[string]int)
+	report := func(field string, index int, selector string) {
+		reported[field] = index
+	}
+
+	got := &testAlternatives{}
+	err = NewUnmarshaler(root, ReportAlternatives(report)).Unmarshal(got)
+	if nme, ok := err.(*NoMatchError); !ok || nme.Field != "Rating" || nme.Selector != ".stars || .rating" {
+		t.Errorf("Wanted NoMatchError for Rating got %v", err)
+	}
+
+	want := &testAlternatives{
+		Price: "9.99",
+		Name:  "Widget",
+		Tags:  []string{"a", "b"},
+		Specs: map[string]string{"Color": "Red"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted %+v got %+v", want, got)
+	}
+
+	wantReported := map[string]int{"Price": 1, "Name": 1, "Tags": 1, "Specs": 1}
+	if !reflect.DeepEqual(wantReported, reported) {
+		t.Errorf("Wanted reported alternatives %v got %v", wantReported, reported)
+	}
+}
+
+type testRegex struct {
+	Price    float64  `scraper:".price" scrapeRegex:"\\$(?P<value>[\\d.]+)"`
+	Reviews  int      `scraper:".reviews" scrapeRegex:"\\d+"`
+	Sizes    []string `scraper:".size" scrapeRegex:"Size: (\\w+)"`
+	Currency string   `scraper:".price" scrapeRegex:"[A-Z]{3}"`
+}
+
+func TestRegex(t *testing.T) {
+	input := `<span class="price">Price: $1299.00 USD (incl. VAT)</span>
+		<span class="reviews">(42 reviews)</span>
+		<span class="size">Size: S</span><span class="size">Size: M</span>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	got := &testRegex{}
+	if err := NewUnmarshaler(root).Unmarshal(got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := &testRegex{Price: 1299, Reviews: 42, Sizes: []string{"S", "M"}, Currency: "USD"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted %+v got %+v", want, got)
+	}
+
+	root, _ = html.Parse(strings.NewReader(`<span class="reviews">No reviews</span>`))
+	err = NewUnmarshaler(root).Unmarshal(&testRegex{})
+	if rme, ok := err.(*RegexMismatchError); !ok || rme.Struct != "testRegex" || rme.Field != "Reviews" || rme.Value != "No reviews" {
+		t.Errorf("Wanted RegexMismatchError for testRegex.Reviews got %v", err)
+	}
+}
+
+type testNormalize struct {
+	Title   string `scraper:"h1"`
+	Summary string `scraper:".summary" scrapeOptions:"normalize"`
+	Body    string `scraper:".summary" scrapeType:"innertext"`
+	HTML    string `scraper:"h1" scrapeType:"html"`
+}
+
+func TestNormalizeSpace(t *testing.T) {
+	input := "<h1>\n\tHello\n        World\u00a0</h1><div class=\"summary\"><p>First  paragraph</p>\n<p>Second<br>paragraph</p></div>"
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	got := &testNormalize{}
+	if err := NewUnmarshaler(root).Unmarshal(got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := &testNormalize{
+		Title:   "\n\tHello\n        World\u00a0",
+		Summary: "First paragraph Secondparagraph",
+		Body:    "First paragraph\nSecond\nparagraph",
+		HTML:    "\n\tHello\n        World\u00a0",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted %+v got %+v", want, got)
+	}
+
+	got = &testNormalize{}
+	if err := NewUnmarshaler(root, NormalizeSpace()).Unmarshal(got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want.Title = "Hello World"
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted %+v got %+v", want, got)
+	}
+}
+
+type testBoolAttrs struct {
+	Checked  []bool `scraper:"input" scrapeType:"attr:checked"`
+	Disabled []bool `scraper:"input" scrapeType:"attr:disabled"`
+	Required bool   `scraper:"input.name" scrapeType:"attr:required"`
+}
+
+func TestBoolAttributes(t *testing.T) {
+	input := `<input class="name" required><input type="checkbox" checked disabled="disabled"><input type="checkbox">`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	got := &testBoolAttrs{}
+	if err := NewUnmarshaler(root).Unmarshal(got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := &testBoolAttrs{Checked: []bool{false, true, false}, Disabled: []bool{false, true, false}, Required: true}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted %+v got %+v", want, got)
+	}
+}
+
+type testOwnTextNumber struct {
+	N     int     `scraper:"td" scrapeType:"owntext"`
+	Price float64 `scraper:"span.price" scrapeType:"owntext"`
+}
+
+func TestOwnTextNumber(t *testing.T) {
+	input := `<table><tr><td>42 <span>units</span></td></tr></table><span class="price"> 9.99 <em>USD</em></span>`
+	root, err := html.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	got := &testOwnTextNumber{}
+	if err := NewUnmarshaler(root).Unmarshal(got); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	want := &testOwnTextNumber{N: 42, Price: 9.99}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted %+v got %+v", want, got)
+	}
+}
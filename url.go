@@ -0,0 +1,117 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var urlType = reflect.TypeOf(url.URL{})
+
+// BaseURL sets the URL that the document was retrieved from.  Values of
+// the `url` scrapeType and of url.URL fields are resolved against it, or
+// against the document's <base href> element, which is itself resolved
+// against the base URL
+func BaseURL(base *url.URL) Option {
+	return func(u *Unmarshaler) error {
+		u.baseURL = base
+		return nil
+	}
+}
+
+// documentBase returns the URL that relative URLs within the document
+// containing n are resolved against.  The first <base href> element in
+// the document is honored, even when n is only part of the document
+func documentBase(n *html.Node, base *url.URL) *url.URL {
+	if n == nil {
+		return base
+	}
+
+	for n.Parent != nil {
+		n = n.Parent
+	}
+
+	if href, found := findBaseHref(n); found {
+		if ref, err := url.Parse(href); err == nil {
+			if base == nil {
+				return ref
+			}
+			return base.ResolveReference(ref)
+		}
+	}
+	return base
+}
+
+func findBaseHref(n *html.Node) (string, bool) {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Base {
+		if href, found := (&selection{n}).lookupAttr("href"); found {
+			return href, true
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if href, found := findBaseHref(c); found {
+			return href, true
+		}
+	}
+	return "", false
+}
+
+// resolvesURL determines if values assigned to f are URLs that
+// should be resolved against the document's base
+func resolvesURL(f *field) bool {
+	t := f.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return f.tag.typ == resolvedURL || t == urlType
+}
+
+// resolve returns value resolved against the document's base.  Surrounding
+// whitespace is removed first, as browsers do.  The value is otherwise returned
+// unchanged when it is empty, there is no base or it is not a valid URL
+func (u *Unmarshaler) resolve(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return value
+	}
+
+	// finding the base walks the whole document, so it
+	// is only done once a URL actually needs resolving
+	u.baseOnce.Do(func() { u.base = documentBase(u.root, u.baseURL) })
+	if u.base == nil {
+		return value
+	}
+
+	ref, err := url.Parse(value)
+	if err != nil {
+		return value
+	}
+	return u.base.ResolveReference(ref).String()
+}
+
+func setURL(v reflect.Value, value string) error {
+	parsed, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return &UnmarshalTypeError{Value: "url " + value, Type: v.Type()}
+	}
+	v.Set(reflect.ValueOf(parsed).Elem())
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 Andrew Bates
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scraper
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+type urlTest struct {
+	Link     string    `scraper:"a" scrapeType:"url:href"`
+	Image    string    `scraper:"img" scrapeType:"url"`
+	Raw      string    `scraper:"a" scrapeType:"attr:href"`
+	URL      url.URL   `scraper:"a" scrapeType:"attr:href"`
+	URLPtr   *url.URL  `scraper:"img" scrapeType:"attr:src"`
+	URLs     []url.URL `scraper:"a" scrapeType:"attr:href"`
+	Absolute string    `scraper:"a.abs" scrapeType:"url"`
+	Empty    string    `scraper:"b.empty" scrapeType:"url:href"`
+	Spaced   url.URL   `scraper:"span.spaced"`
+}
+
+func TestResolveURL(t *testing.T) {
+	base, _ := url.Parse("https://example.com/products/widget")
+	tests := []struct {
+		name     string
+		input    string
+		base     *url.URL
+		wantLink string
+		wantImg  string
+	}{
+		{"no base", "", nil, "../p/123", "img.png"},
+		{"base option", "", base, "https://example.com/p/123", "https://example.com/products/img.png"},
+		{"base element", `<base href="https://cdn.example.com/a/b/">`, nil, "https://cdn.example.com/a/p/123", "https://cdn.example.com/a/b/img.png"},
+		{"relative base element", `<base href="/static/">`, base, "https://example.com/p/123", "https://example.com/static/img.png"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := `<html><head>` + test.input + `</head><body>
+				<a href="../p/123">Widget</a>
+				<a class="abs" href="https://other.example.com/">Other</a>
+				<img src="img.png">
+				<b class="empty">Missing</b>
+				<span class="spaced"> /q </span>
+			</body></html>`
+			root, err := html.Parse(strings.NewReader(input))
+			if err != nil {
+				t.Fatalf("Failed to parse html: %v", err)
+			}
+
+			got := &urlTest{}
+			if err := NewUnmarshaler(root, BaseURL(test.base)).Unmarshal(got); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+
+			if got.Link != test.wantLink {
+				t.Errorf("Wanted link %q got %q", test.wantLink, got.Link)
+			}
+
+			if got.Image != test.wantImg {
+				t.Errorf("Wanted image %q got %q", test.wantImg, got.Image)
+			}
+
+			if got.Raw != "../p/123" {
+				t.Errorf("Wanted unresolved attribute got %q", got.Raw)
+			}
+
+			if got.URL.String() != test.wantLink {
+				t.Errorf("Wanted URL %q got %q", test.wantLink, got.URL.String())
+			}
+
+			if got.URLPtr == nil || got.URLPtr.String() != test.wantImg {
+				t.Errorf("Wanted URL pointer %q got %v", test.wantImg, got.URLPtr)
+			}
+
+			if len(got.URLs) != 2 || got.URLs[0].String() != test.wantLink {
+				t.Errorf("Wanted URLs starting with %q got %v", test.wantLink, got.URLs)
+			}
+
+			if got.Absolute != "https://other.example.com/" {
+				t.Errorf("Wanted absolute URL to be unchanged got %q", got.Absolute)
+			}
+
+			if got.Empty != "" {
+				t.Errorf("Wanted missing attribute to stay empty got %q", got.Empty)
+			}
+
+			if got.Spaced.Path != "/q" {
+				t.Errorf("Wanted trimmed path %q got %q", "/q", got.Spaced.Path)
+			}
+		})
+	}
+}
+
+func TestResolveURLLazily(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<html><head><base href="https://example.com/"></head><body><a href="/p">P</a></body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse html: %v", err)
+	}
+
+	u := NewUnmarshaler(root)
+	if err := u.Unmarshal(&struct {
+		Raw string `scraper:"a" scrapeType:"attr:href"`
+	}{}); err != nil || u.base != nil {
+		t.Errorf("Wanted the base to be found only when resolving a URL got %v (%v)", u.base, err)
+	}
+
+	got := &struct {
+		Link string `scraper:"a" scrapeType:"url"`
+	}{}
+	if err := u.Unmarshal(got); err != nil || got.Link != "https://example.com/p" {
+		t.Errorf("Wanted resolved link got %q (%v)", got.Link, err)
+	}
+}